@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+)
+
+// purgeDeletedAccounts removes accounts whose deletion was requested more
+// than gracePeriod ago. Purging an account twice is harmless, so it can run
+// on every instance.
+func purgeDeletedAccounts(db *sql.DB, queries *database.Queries, gracePeriod time.Duration) {
+	check := time.Hour
+	if gracePeriod < check {
+		check = gracePeriod
+	}
+
+	for {
+		ctx := context.Background()
+		cutoff := sql.NullTime{Time: time.Now().Add(-gracePeriod), Valid: true}
+		userIDs, err := queries.GetUsersToPurge(ctx, cutoff)
+		if err != nil {
+			log.Printf("Cannot list deleted accounts: %s", err)
+		}
+
+		purged := 0
+		for _, userID := range userIDs {
+			if err := purgeAccount(ctx, db, queries, userID); err != nil {
+				log.Printf("Cannot purge account %s: %s", userID, err)
+				continue
+			}
+			purged++
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+
+		time.Sleep(check)
+	}
+}
+
+// purgeAccount deletes a user and their chirps. Chirps that have replies or
+// quotes are tombstoned instead, so threads keep their shape.
+func purgeAccount(ctx context.Context, db *sql.DB, queries *database.Queries, userID uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	author := uuid.NullUUID{UUID: userID, Valid: true}
+
+	// Likes cascade away with the user, so their counts have to go first.
+	if err := qtx.DecrementLikeCountsOfUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteRechirpsOfUser(ctx, author); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpRevisionsOfUser(ctx, author); err != nil {
+		return err
+	}
+	if err := qtx.TombstoneChirpsOfUser(ctx, author); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpsOfUser(ctx, author); err != nil {
+		return err
+	}
+	if err := qtx.DeleteUserByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// SigningKey is a private key access tokens are signed with. Tokens name
+// the key in their kid header so verifiers can pick the matching public key.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// GenerateSigningKey creates an Ed25519 key. Its ID is derived from now so
+// that sorting key IDs sorts keys by age.
+func GenerateSigningKey(now time.Time) (SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("cannot generate signing key: %w", err)
+	}
+	return SigningKey{
+		ID:        now.UTC().Format("20060102T150405Z"),
+		Method:    jwt.SigningMethodEdDSA,
+		Private:   private,
+		CreatedAt: now,
+	}, nil
+}
+
+// ParseSigningKeyPEM reads a PKCS #8 Ed25519 or RSA private key. Ed25519
+// keys sign with EdDSA, RSA keys with RS256.
+func ParseSigningKeyPEM(id string, data []byte, createdAt time.Time) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("key %s: no PEM data found", id)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	key := SigningKey{ID: id, CreatedAt: createdAt}
+	switch private := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = private
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Private = private
+	default:
+		return SigningKey{}, fmt.Errorf("key %s: unsupported key type %T", id, parsed)
+	}
+	return key, nil
+}
+
+// MarshalPEM encodes the private key the way ParseSigningKeyPEM reads it.
+func (k SigningKey) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", k.ID, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k SigningKey) jwk() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch public := k.Private.Public().(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+	return jwk
+}
+
+type verificationKey struct {
+	key SigningKey
+	// retiresAt is when the last token signed with the key expires. It is
+	// zero for the current key.
+	retiresAt time.Time
+}
+
+// KeySet signs access tokens with its current key and verifies them with
+// any key that has not retired yet. A key that is rotated out keeps
+// verifying for verifyFor, the lifetime of an access token, so rotation
+// never logs anyone out.
+//
+// Without a current key the set falls back to HS256 with the legacy shared
+// secret. Once it has keys, HS256 tokens without a kid are only accepted
+// until verifyFor after the oldest key was created, so tokens issued before
+// the switch keep working until they expire but the secret can't mint new
+// ones.
+type KeySet struct {
+	mu           sync.RWMutex
+	current      *SigningKey
+	keys         map[string]verificationKey
+	legacySecret []byte
+	legacyUntil  time.Time
+	verifyFor    time.Duration
+}
+
+func NewKeySet(legacySecret []byte, verifyFor time.Duration) *KeySet {
+	return &KeySet{
+		keys:         map[string]verificationKey{},
+		legacySecret: legacySecret,
+		verifyFor:    verifyFor,
+	}
+}
+
+// Current returns the key new tokens are signed with.
+func (ks *KeySet) Current() (SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.current == nil {
+		return SigningKey{}, false
+	}
+	return *ks.current, true
+}
+
+// AddRetired adds a key that only verifies tokens, until retiresAt.
+func (ks *KeySet) AddRetired(key SigningKey, retiresAt time.Time) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.ID] = verificationKey{key: key, retiresAt: retiresAt}
+}
+
+// Rotate makes key the signing key. The previous one keeps verifying until
+// the tokens it signed have expired.
+func (ks *KeySet) Rotate(key SigningKey, now time.Time) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.current != nil {
+		ks.keys[ks.current.ID] = verificationKey{key: *ks.current, retiresAt: now.Add(ks.verifyFor)}
+	} else {
+		// The switch away from the legacy secret happened when the oldest
+		// key we know of was created.
+		oldest := key.CreatedAt
+		for _, vk := range ks.keys {
+			if vk.key.CreatedAt.Before(oldest) {
+				oldest = vk.key.CreatedAt
+			}
+		}
+		ks.legacyUntil = oldest.Add(ks.verifyFor)
+	}
+	ks.current = &key
+	ks.keys[key.ID] = verificationKey{key: key}
+}
+
+// Prune drops retired keys no valid token can be signed with anymore and
+// returns their IDs.
+func (ks *KeySet) Prune(now time.Time) []string {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	var pruned []string
+	for id, vk := range ks.keys {
+		if !vk.retiresAt.IsZero() && now.After(vk.retiresAt) {
+			delete(ks.keys, id)
+			pruned = append(pruned, id)
+		}
+	}
+	return pruned
+}
+
+// MakeJWT signs an access token with the current key, or the legacy secret
+// if there is none yet. uuid.Nil leaves the session out.
+func (ks *KeySet) MakeJWT(userID, sessionID uuid.UUID, expiresIn time.Duration) (string, error) {
+	current, ok := ks.Current()
+	if !ok {
+		return makeHS256JWT(userID, sessionID, ks.legacySecret, expiresIn)
+	}
+
+	token := jwt.NewWithClaims(current.Method, newAccessTokenClaims(userID, sessionID, expiresIn))
+	token.Header["kid"] = current.ID
+	return token.SignedString(current.Private)
+}
+
+// ParseJWT validates an access token signed by any key in the set.
+func (ks *KeySet) ParseJWT(tokenString string) (Claims, error) {
+	return parseAccessToken(tokenString, ks.keyFunc)
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(ks.legacySecret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		if ks.current != nil && time.Now().After(ks.legacyUntil) {
+			return nil, fmt.Errorf("tokens signed with the legacy secret are no longer accepted")
+		}
+		return hmacKeyFunc(ks.legacySecret)(token)
+	}
+
+	vk, ok := ks.keys[kid]
+	if !ok || (!vk.retiresAt.IsZero() && time.Now().After(vk.retiresAt)) {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != vk.key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v for key %q", token.Header["alg"], kid)
+	}
+	return vk.key.Private.Public(), nil
+}
+
+// JWKS returns the public halves of every key that can still verify a
+// token, for services that check our tokens on their own.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	jwks := JWKS{Keys: []JWK{}}
+	for _, vk := range ks.keys {
+		jwks.Keys = append(jwks.Keys, vk.key.jwk())
+	}
+	return jwks
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	edKey, err := GenerateSigningKey(time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  SigningKey
+	}{
+		{name: "EdDSA", key: edKey},
+		{name: "RS256", key: SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, Private: rsaKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := NewKeySet(nil, time.Hour)
+			ks.Rotate(tt.key, time.Now())
+
+			userID := uuid.New()
+			tokenString, err := ks.MakeJWT(userID, uuid.Nil, time.Hour)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			claims, err := ks.ParseJWT(tokenString)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if claims.UserID != userID {
+				t.Errorf("expected userID %v, got %v", userID, claims.UserID)
+			}
+
+			jwks := ks.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != tt.key.ID || jwks.Keys[0].Alg != tt.key.Method.Alg() {
+				t.Errorf("unexpected JWKS %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	now := time.Now()
+	first, err := GenerateSigningKey(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := GenerateSigningKey(now)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ks := NewKeySet(nil, time.Hour)
+	ks.Rotate(first, now)
+	oldToken, err := ks.MakeJWT(uuid.New(), uuid.Nil, time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ks.Rotate(second, now)
+	if current, _ := ks.Current(); current.ID != second.ID {
+		t.Errorf("expected current key %s, got %s", second.ID, current.ID)
+	}
+
+	// Tokens signed before the rotation stay valid
+	if _, err := ks.ParseJWT(oldToken); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if pruned := ks.Prune(now); len(pruned) != 0 {
+		t.Errorf("expected nothing pruned, got %v", pruned)
+	}
+
+	// Until the retired key is pruned
+	pruned := ks.Prune(now.Add(2 * time.Hour))
+	if len(pruned) != 1 || pruned[0] != first.ID {
+		t.Errorf("expected %s pruned, got %v", first.ID, pruned)
+	}
+	if _, err := ks.ParseJWT(oldToken); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestKeySetLegacySecret(t *testing.T) {
+	userID := uuid.New()
+	legacyToken, err := MakeJWT(userID, "mysecret", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	key, err := GenerateSigningKey(time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ks := NewKeySet([]byte("mysecret"), time.Hour)
+	ks.Rotate(key, time.Now())
+
+	claims, err := ks.ParseJWT(legacyToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected userID %v, got %v", userID, claims.UserID)
+	}
+
+	// Without the secret, HS256 tokens are rejected
+	ks = NewKeySet(nil, time.Hour)
+	ks.Rotate(key, time.Now())
+	if _, err := ks.ParseJWT(legacyToken); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	// And so are they once the switch is longer ago than a token lives
+	oldKey, err := GenerateSigningKey(time.Now().Add(-2 * time.Hour))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ks = NewKeySet([]byte("mysecret"), time.Hour)
+	ks.AddRetired(oldKey, time.Now().Add(-time.Hour))
+	ks.Rotate(key, time.Now())
+	if _, err := ks.ParseJWT(legacyToken); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+}
+
+func TestSigningKeyPEMRoundTrip(t *testing.T) {
+	key, err := GenerateSigningKey(time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data, err := key.MarshalPEM()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	parsed, err := ParseSigningKeyPEM(key.ID, data, key.CreatedAt)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if parsed.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("expected EdDSA, got %v", parsed.Method.Alg())
+	}
+	if parsed.jwk().X != key.jwk().X {
+		t.Errorf("expected same public key after round trip")
+	}
+}
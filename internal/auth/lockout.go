@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"time"
+)
+
+// LockoutPolicy decides how long logins are refused after repeated
+// failures: not at all for the first few, then for a delay that doubles
+// with every further failure, and for LockoutDuration once Threshold is
+// reached.
+type LockoutPolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	Threshold       int
+	LockoutDuration time.Duration
+}
+
+// Delay returns how long to refuse logins after failures consecutive
+// failed attempts.
+func (p LockoutPolicy) Delay(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	if failures >= p.Threshold {
+		return p.LockoutDuration
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= p.LockoutDuration {
+			return p.LockoutDuration
+		}
+	}
+	return delay
+}
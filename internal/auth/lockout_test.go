@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyDelay(t *testing.T) {
+	policy := LockoutPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		Threshold:       10,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 2, expected: 0},
+		{failures: 3, expected: time.Second},
+		{failures: 4, expected: 2 * time.Second},
+		{failures: 6, expected: 8 * time.Second},
+		{failures: 9, expected: 64 * time.Second},
+		{failures: 10, expected: 15 * time.Minute},
+		{failures: 50, expected: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.expected {
+			t.Errorf("after %d failures: expected %v, got %v", tt.failures, tt.expected, got)
+		}
+	}
+}
+
+func TestLockoutPolicyDelayCapped(t *testing.T) {
+	policy := LockoutPolicy{
+		FreeAttempts:    1,
+		BaseDelay:       time.Minute,
+		Threshold:       100,
+		LockoutDuration: 5 * time.Minute,
+	}
+
+	if got := policy.Delay(20); got != 5*time.Minute {
+		t.Errorf("expected delay capped at %v, got %v", 5*time.Minute, got)
+	}
+}
@@ -22,3 +22,15 @@ func HashPassword(password string) (string, error) {
 func ComparePassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// dummyHash is a bcrypt hash at the cost HashPassword uses, of a password
+// nobody knows.
+const dummyHash = "$2a$10$xL0Lcrtmx3H7QCn6fGxryuNAbmnLqBBksgkWllyGccUsuptCaR/.e"
+
+// DummyComparePassword costs as much as ComparePassword and always fails.
+// Logins for unknown emails call it so they take as long as wrong passwords,
+// and response times don't reveal which emails have accounts.
+func DummyComparePassword(password string) error {
+	ComparePassword(dummyHash, password)
+	return bcrypt.ErrMismatchedHashAndPassword
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -27,3 +29,23 @@ func TestHashPasswordEmpty(t *testing.T) {
 		t.Fatalf("expected error for empty password, got nil")
 	}
 }
+
+func TestDummyComparePassword(t *testing.T) {
+	if err := DummyComparePassword("mysecretpassword"); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+
+	// The dummy hash must cost as much as a real one, or timing leaks
+	hashedPassword, err := HashPassword("mysecretpassword")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	realCost, _ := bcrypt.Cost([]byte(hashedPassword))
+	dummyCost, err := bcrypt.Cost([]byte(dummyHash))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if dummyCost != realCost {
+		t.Errorf("expected dummy cost %d, got %d", realCost, dummyCost)
+	}
+}
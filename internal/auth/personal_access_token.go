@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+)
+
+// personalAccessTokenPrefix tells personal access tokens apart from JWTs in
+// the Authorization header, and makes leaked ones easy to scan for.
+const personalAccessTokenPrefix = "chirpy_pat_"
+
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeProfileWrite = "profile:write"
+	ScopeFollowsWrite = "follows:write"
+)
+
+var scopes = map[string]bool{
+	ScopeChirpsRead:   true,
+	ScopeChirpsWrite:  true,
+	ScopeProfileWrite: true,
+	ScopeFollowsWrite: true,
+}
+
+func ValidScope(scope string) bool {
+	return scopes[scope]
+}
+
+func MakePersonalAccessToken() (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return personalAccessTokenPrefix + token, nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, personalAccessTokenPrefix)
+}
+
+// HasScope reports whether granted includes scope. An empty scope is never
+// granted: routes without one are closed to personal access tokens.
+func HasScope(granted []string, scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
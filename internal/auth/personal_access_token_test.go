@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMakePersonalAccessToken(t *testing.T) {
+	token, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("expected %q to be a personal access token", token)
+	}
+
+	jwt, err := MakeJWT(uuid.New(), "mysecret", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if IsPersonalAccessToken(jwt) {
+		t.Errorf("expected JWT not to be a personal access token")
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	granted := []string{ScopeChirpsRead, ScopeChirpsWrite}
+
+	tests := []struct {
+		name     string
+		scope    string
+		expected bool
+	}{
+		{name: "granted", scope: ScopeChirpsWrite, expected: true},
+		{name: "not granted", scope: ScopeProfileWrite, expected: false},
+		{name: "no scope", scope: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(granted, tt.scope); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
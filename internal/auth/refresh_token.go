@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 )
@@ -16,3 +17,11 @@ func MakeRefreshToken() (string, error) {
 	refreshToken := hex.EncodeToString(tokenBytes)
 	return refreshToken, nil
 }
+
+// HashToken returns the form refresh and personal access tokens are stored
+// in. The tokens are 256 random bits, so an unsalted SHA-256 is enough to
+// make a leaked table useless without slowing down every request.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestHashToken(t *testing.T) {
+	// Must match encode(sha256(convert_to(token, 'UTF8')), 'hex') in the
+	// migration that hashed the existing tokens.
+	expected := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashToken("abc"); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestHashTokenDiffers(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if HashToken(token) == token {
+		t.Errorf("expected hash to differ from token")
+	}
+}
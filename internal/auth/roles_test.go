@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		required string
+		expected bool
+	}{
+		{name: "user as user", role: RoleUser, required: RoleUser, expected: true},
+		{name: "user as moderator", role: RoleUser, required: RoleModerator, expected: false},
+		{name: "moderator as moderator", role: RoleModerator, required: RoleModerator, expected: true},
+		{name: "moderator as admin", role: RoleModerator, required: RoleAdmin, expected: false},
+		{name: "admin as moderator", role: RoleAdmin, required: RoleModerator, expected: true},
+		{name: "unknown role", role: "root", required: RoleUser, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRole(tt.role, tt.required); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -9,44 +9,90 @@ import (
 	"github.com/google/uuid"
 )
 
+// Claims are the parts of a validated access token the server acts on.
+type Claims struct {
+	UserID uuid.UUID
+	// SessionID is the refresh token family the access token was issued
+	// for. It is only missing from tokens issued before sessions existed.
+	SessionID uuid.NullUUID
+}
+
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
-
-	return token.SignedString([]byte(tokenSecret))
+	return makeHS256JWT(userID, uuid.Nil, []byte(tokenSecret), expiresIn)
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&jwt.RegisteredClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(tokenSecret), nil
+	claims, err := parseAccessToken(tokenString, hmacKeyFunc([]byte(tokenSecret)))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// makeHS256JWT signs an access token with a shared secret. uuid.Nil leaves
+// the session out.
+func makeHS256JWT(userID, sessionID uuid.UUID, secret []byte, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newAccessTokenClaims(userID, sessionID, expiresIn))
+	return token.SignedString(secret)
+}
+
+// hmacKeyFunc verifies tokens against a shared secret and refuses any other
+// signing method.
+func hmacKeyFunc(secret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	}
+}
+
+func newAccessTokenClaims(userID, sessionID uuid.UUID, expiresIn time.Duration) accessTokenClaims {
+	claims := accessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			Subject:   userID.String(),
 		},
-	)
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+	return claims
+}
 
+func parseAccessToken(tokenString string, keyFunc jwt.Keyfunc) (Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &accessTokenClaims{}, keyFunc)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid token: %v", err)
+		return Claims{}, fmt.Errorf("invalid token: %v", err)
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*accessTokenClaims)
 	if !ok || !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token claims")
+		return Claims{}, fmt.Errorf("invalid token claims")
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID in token")
+		return Claims{}, fmt.Errorf("invalid user ID in token")
+	}
+
+	result := Claims{UserID: userID}
+	if claims.SessionID != "" {
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return Claims{}, fmt.Errorf("invalid session ID in token")
+		}
+		result.SessionID = uuid.NullUUID{UUID: sessionID, Valid: true}
 	}
 
-	return userID, nil
+	return result, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -96,6 +96,42 @@ func TestValidateJWT(t *testing.T) {
 		t.Fatalf("expected error, got none")
 	}
 }
+
+func TestParseJWTSession(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	ks := NewKeySet([]byte("mysecret"), time.Hour)
+
+	tokenString, err := ks.MakeJWT(userID, sessionID, time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	claims, err := ks.ParseJWT(tokenString)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("expected userID %v, got %v", userID, claims.UserID)
+	}
+	if !claims.SessionID.Valid || claims.SessionID.UUID != sessionID {
+		t.Errorf("expected sessionID %v, got %v", sessionID, claims.SessionID)
+	}
+
+	// Tokens without a session still validate
+	tokenString, err = MakeJWT(userID, "mysecret", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	claims, err = ks.ParseJWT(tokenString)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.SessionID.Valid {
+		t.Errorf("expected no session, got %v", claims.SessionID)
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name          string
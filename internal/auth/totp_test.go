@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from the test vectors in RFC 6238, appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; ours are their last 6 digits.
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if code != tt.expected {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.expected, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	var clock Clock = fixedClock(time.Unix(1111111111, 0))
+	code, err := TOTPCode(rfcSecret, clock.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	counter, ok := ValidateTOTP(rfcSecret, code, clock.Now())
+	if !ok {
+		t.Fatalf("expected code to be valid")
+	}
+	if counter != clock.Now().Unix()/totpPeriod {
+		t.Errorf("expected counter %d, got %d", clock.Now().Unix()/totpPeriod, counter)
+	}
+
+	// One period of drift is tolerated
+	if _, ok := ValidateTOTP(rfcSecret, code, clock.Now().Add(totpPeriod*time.Second)); !ok {
+		t.Errorf("expected code from previous period to be valid")
+	}
+
+	// Two is not
+	if _, ok := ValidateTOTP(rfcSecret, code, clock.Now().Add(2*totpPeriod*time.Second)); ok {
+		t.Errorf("expected code from two periods ago to be invalid")
+	}
+
+	if _, ok := ValidateTOTP(rfcSecret, "000000", clock.Now()); ok {
+		t.Errorf("expected wrong code to be invalid")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Errorf("expected code for generated secret to be valid")
+	}
+
+	uri := TOTPURI(secret, "Chirpy", "walt@breakingbad.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Chirpy:walt@breakingbad.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected URI %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("expected 10 codes, got %d", len(codes))
+	}
+
+	code := codes[0]
+	typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+	if got := NormalizeRecoveryCode(typed); got != code {
+		t.Errorf("expected %s, got %s", code, got)
+	}
+}
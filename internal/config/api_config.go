@@ -1,18 +1,36 @@
 package config
 
 import (
+	"database/sql"
 	"html/template"
 	"sync/atomic"
+	"time"
 
+	"github.com/onkelwolle/chirpy/internal/auth"
 	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/mail"
+	"github.com/onkelwolle/chirpy/internal/moderation"
 )
 
 type ApiConfig struct {
-	FileserverHits        atomic.Int32
-	Templates             *template.Template
-	DbQueries             *database.Queries
-	Secret                []byte
-	PolkaWebhookSecret    []byte
-	AccessTokenExpiresIn  int64
-	RefreshTokenExpiresIn int64
+	FileserverHits       atomic.Int32
+	Templates            *template.Template
+	DB                   *sql.DB
+	DbQueries            *database.Queries
+	Secret               []byte
+	Keys                 *auth.KeySet
+	Clock                auth.Clock
+	AccountLockout       auth.LockoutPolicy
+	IPLockout            auth.LockoutPolicy
+	Mailer               mail.Mailer
+	PublicURL            string
+	RequireVerifiedEmail bool
+	// AccountDeletionGracePeriod is how long a deleted account can still be
+	// restored by logging in before it is purged.
+	AccountDeletionGracePeriod time.Duration
+	PolkaWebhookSecret         []byte
+	AccessTokenExpiresIn       int64
+	RefreshTokenExpiresIn      int64
+	Moderation                 *moderation.Chain
+	BannedWords                *moderation.WordList
 }
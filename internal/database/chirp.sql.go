@@ -7,29 +7,61 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasDependents = `-- name: ChirpHasDependents :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_id = $1::uuid
+        OR quote_of = $1::uuid
+)
+`
+
+func (q *Queries) ChirpHasDependents(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasDependents, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of, quote_of)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.NullUUID
+	ParentID  uuid.NullUUID
+	RootID    uuid.NullUUID
+	RechirpOf uuid.NullUUID
+	QuoteOf   uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RootID,
+		arg.RechirpOf,
+		arg.QuoteOf,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,10 +69,37 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
+const decrementChirpLikeCount = `-- name: DecrementChirpLikeCount :exec
+UPDATE chirps SET like_count = GREATEST(like_count - 1, 0) WHERE id = $1
+`
+
+func (q *Queries) DecrementChirpLikeCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpLikeCount, id)
+	return err
+}
+
+const decrementLikeCountsOfUser = `-- name: DecrementLikeCountsOfUser :exec
+UPDATE chirps SET like_count = GREATEST(like_count - 1, 0)
+WHERE id IN (SELECT chirp_id FROM chirp_likes WHERE user_id = $1)
+`
+
+func (q *Queries) DecrementLikeCountsOfUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementLikeCountsOfUser, userID)
+	return err
+}
+
 const deleteChirpByID = `-- name: DeleteChirpByID :exec
 DELETE FROM chirps WHERE id = $1
 `
@@ -50,8 +109,47 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteChirps = `-- name: DeleteChirps :exec
+DELETE FROM chirps
+`
+
+func (q *Queries) DeleteChirps(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteChirps)
+	return err
+}
+
+const deleteChirpsOfUser = `-- name: DeleteChirpsOfUser :exec
+DELETE FROM chirps WHERE user_id = $1 AND deleted_at IS NULL
+`
+
+// Tombstones are kept and lose their author once the user is deleted.
+func (q *Queries) DeleteChirpsOfUser(ctx context.Context, userID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpsOfUser, userID)
+	return err
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps WHERE rechirp_of = $1
+`
+
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, rechirpOf uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, rechirpOf)
+	return err
+}
+
+const deleteRechirpsOfUser = `-- name: DeleteRechirpsOfUser :exec
+DELETE FROM chirps
+WHERE rechirp_of IN (SELECT id FROM chirps WHERE user_id = $1)
+`
+
+// Removes other users' rechirps of the user's chirps.
+func (q *Queries) DeleteRechirpsOfUser(ctx context.Context, userID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOfUser, userID)
+	return err
+}
+
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps WHERE id = $1
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -63,16 +161,43 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
 const getChirpsAsc = `-- name: GetChirpsAsc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps
+WHERE deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = $1 OR $2::bool)
+    AND (created_at, id) > ($3::timestamp, $4::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
 `
 
-func (q *Queries) GetChirpsAsc(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsAsc)
+type GetChirpsAscParams struct {
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetChirpsAsc(ctx context.Context, arg GetChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsAsc,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -86,6 +211,55 @@ func (q *Queries) GetChirpsAsc(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -101,11 +275,33 @@ func (q *Queries) GetChirpsAsc(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUserIDAsc = `-- name: GetChirpsByUserIDAsc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps
+WHERE user_id = $1
+    AND deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = $2 OR $3::bool)
+    AND (created_at, id) > ($4::timestamp, $5::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $6
 `
 
-func (q *Queries) GetChirpsByUserIDAsc(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserIDAsc, userID)
+type GetChirpsByUserIDAscParams struct {
+	UserID          uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetChirpsByUserIDAsc(ctx context.Context, arg GetChirpsByUserIDAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserIDAsc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +315,14 @@ func (q *Queries) GetChirpsByUserIDAsc(ctx context.Context, userID uuid.UUID) ([
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -134,11 +338,33 @@ func (q *Queries) GetChirpsByUserIDAsc(ctx context.Context, userID uuid.UUID) ([
 }
 
 const getChirpsByUserIDDesc = `-- name: GetChirpsByUserIDDesc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at DESC
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps
+WHERE user_id = $1
+    AND deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = $2 OR $3::bool)
+    AND (created_at, id) < ($4::timestamp, $5::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
 `
 
-func (q *Queries) GetChirpsByUserIDDesc(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserIDDesc, userID)
+type GetChirpsByUserIDDescParams struct {
+	UserID          uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetChirpsByUserIDDesc(ctx context.Context, arg GetChirpsByUserIDDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserIDDesc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -152,6 +378,14 @@ func (q *Queries) GetChirpsByUserIDDesc(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -167,11 +401,159 @@ func (q *Queries) GetChirpsByUserIDDesc(ctx context.Context, userID uuid.UUID) (
 }
 
 const getChirpsDesc = `-- name: GetChirpsDesc :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at DESC
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps
+WHERE deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = $1 OR $2::bool)
+    AND (created_at, id) < ($3::timestamp, $4::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpThread = `-- name: GetChirpThread :many
+WITH RECURSIVE thread AS (
+    SELECT
+        c.id,
+        0 AS depth,
+        ARRAY[to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text] AS path
+    FROM chirps c
+    WHERE c.id = (SELECT COALESCE(root_id, id) FROM chirps WHERE chirps.id = $1)
+    UNION ALL
+    SELECT
+        c.id,
+        thread.depth + 1,
+        thread.path || (to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text)
+    FROM chirps c
+    JOIN thread ON c.parent_id = thread.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.deleted_at, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.hidden_at, thread.depth::int AS depth
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.path
+`
+
+type GetChirpThreadRow struct {
+	Chirp Chirp
+	Depth int32
+}
+
+// Returns the whole conversation a chirp belongs to, depth first with older
+// replies first. Hidden chirps are included; callers decide who sees them.
+func (q *Queries) GetChirpThread(ctx context.Context, id uuid.UUID) ([]GetChirpThreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpThread, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpThreadRow
+	for rows.Next() {
+		var i GetChirpThreadRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.HiddenAt,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at FROM chirps
+WHERE deleted_at IS NULL
+    AND (
+        user_id = $1
+        OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+    )
+    AND (hidden_at IS NULL OR user_id = $1)
+    AND (created_at, id) < ($2::timestamp, $3::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsDesc)
+type GetTimelineParams struct {
+	UserID          uuid.NullUUID
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	PageSize        int32
+}
+
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +567,14 @@ func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentID,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -198,3 +588,197 @@ func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const hideChirp = `-- name: HideChirp :exec
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, id)
+	return err
+}
+
+const hideChirpsOfDeletedUser = `-- name: HideChirpsOfDeletedUser :exec
+UPDATE chirps
+    SET hidden_at = users.deletion_requested_at
+FROM users
+WHERE chirps.user_id = users.id
+    AND users.id = $1
+    AND users.deletion_requested_at IS NOT NULL
+    AND chirps.hidden_at IS NULL
+`
+
+// Hidden chirps are stamped with the deletion request, so cancelling the
+// deletion can tell them apart from chirps hidden by moderators.
+func (q *Queries) HideChirpsOfDeletedUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirpsOfDeletedUser, id)
+	return err
+}
+
+const incrementChirpLikeCount = `-- name: IncrementChirpLikeCount :exec
+UPDATE chirps SET like_count = like_count + 1 WHERE id = $1
+`
+
+func (q *Queries) IncrementChirpLikeCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpLikeCount, id)
+	return err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT
+    chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_id, chirps.root_id, chirps.deleted_at, chirps.like_count, chirps.rechirp_of, chirps.quote_of, chirps.hidden_at,
+    ts_rank(chirps.search_vector, q)::real AS rank,
+    ts_headline(
+        'english',
+        replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        q,
+        'StartSel=<mark>, StopSel=</mark>'
+    ) AS snippet
+FROM chirps, to_tsquery('english', $1) q
+WHERE chirps.search_vector @@ q
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND ($2::uuid IS NULL OR chirps.user_id = $2)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $3
+OFFSET $4
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	AuthorID   uuid.NullUUID
+	PageSize   int32
+	PageOffset int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+// The body is HTML-escaped before highlighting, so the <mark> tags are the
+// only markup in a snippet.
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.HiddenAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+    SET body = '',
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const tombstoneChirpsOfUser = `-- name: TombstoneChirpsOfUser :exec
+UPDATE chirps
+    SET body = '',
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND deleted_at IS NULL
+    AND EXISTS (
+        SELECT 1 FROM chirps d
+        WHERE d.parent_id = chirps.id OR d.quote_of = chirps.id
+    )
+`
+
+func (q *Queries) TombstoneChirpsOfUser(ctx context.Context, userID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirpsOfUser, userID)
+	return err
+}
+
+const unhideChirpsOfDeletedUser = `-- name: UnhideChirpsOfDeletedUser :exec
+UPDATE chirps
+    SET hidden_at = NULL
+FROM users
+WHERE chirps.user_id = users.id
+    AND users.id = $1
+    AND chirps.hidden_at = users.deletion_requested_at
+`
+
+// Must run before the deletion request is cleared.
+func (q *Queries) UnhideChirpsOfDeletedUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unhideChirpsOfDeletedUser, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+    SET body = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_id, root_id, deleted_at, like_count, rechirp_of, quote_of, hidden_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentID,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.HiddenAt,
+	)
+	return i, err
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// DeleteCurrentUser schedules the caller's account for deletion. Until the
+// grace period is over the account is only hidden: its chirps disappear,
+// every session is logged out and its tokens stop working, but logging in
+// again restores it. After that the purge worker removes it for good.
+func (u *usersHandler) DeleteCurrentUser(w http.ResponseWriter, r *http.Request) {
+	current := middleware.MustUser(r.Context())
+
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !u.confirmPassword(w, r, current, params.Password) {
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete account", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	user, err := qtx.RequestUserDeletion(r.Context(), database.RequestUserDeletionParams{
+		DeletionRequestedAt: sql.NullTime{Time: u.cfg.Clock.Now(), Valid: true},
+		ID:                  current.ID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete account", err)
+		return
+	}
+
+	if err := qtx.HideChirpsOfDeletedUser(r.Context(), user.ID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete account", err)
+		return
+	}
+
+	_, err = qtx.RevokeUserSessions(r.Context(), database.RevokeUserSessionsParams{UserID: user.ID})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete account", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete account", err)
+		return
+	}
+
+	purgeAfter := user.DeletionRequestedAt.Time.Add(u.cfg.AccountDeletionGracePeriod)
+	log.Printf("User %s asked to delete their account, purging after %s", user.ID, purgeAfter.Format(time.RFC3339))
+	utils.RespondWithJSON(w, http.StatusAccepted, struct {
+		PurgeAfter string `json:"purge_after"`
+	}{
+		PurgeAfter: purgeAfter.Format(time.RFC3339),
+	})
+}
+
+// cancelAccountDeletion restores an account waiting to be purged, along
+// with the chirps hidden when its deletion was requested. If that fails the
+// login has already been answered with a 500.
+func (u *usersHandler) cancelAccountDeletion(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't restore account", err)
+		return false
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.UnhideChirpsOfDeletedUser(r.Context(), userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't restore account", err)
+		return false
+	}
+	if err := qtx.CancelUserDeletion(r.Context(), userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't restore account", err)
+		return false
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't restore account", err)
+		return false
+	}
+
+	log.Printf("User %s logged in and cancelled the deletion of their account", userID)
+	return true
+}
@@ -1,19 +1,22 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
-	"github.com/onkelwolle/chirpy/internal/auth"
 	"github.com/onkelwolle/chirpy/internal/config"
 	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
 	"github.com/onkelwolle/chirpy/internal/models"
 	utils "github.com/onkelwolle/chirpy/internal/utils"
 )
 
+const maxChirpLength = 140
+
 type chirpHandler struct {
 	cfg *config.ApiConfig
 }
@@ -24,64 +27,137 @@ func NewChirpHandler(cfg *config.ApiConfig) *chirpHandler {
 
 func (h *chirpHandler) CreateChirps(w http.ResponseWriter, r *http.Request) {
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
-		return
-	}
-	userId, err := auth.ValidateJWT(bearerToken, string(h.cfg.Secret))
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
-		return
-	}
+	userId := middleware.MustUser(r.Context()).ID
 
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string `json:"body"`
+		ReplyTo   string `json:"reply_to"`
+		RechirpOf string `json:"rechirp_of"`
+		QuoteOf   string `json:"quote_of"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
 	chirp := parameters{}
-	err = decoder.Decode(&chirp)
+	err := decoder.Decode(&chirp)
 	if err != nil {
 		log.Printf("Error decoding parameters: %s", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
 
-	const maxChirpLength = 140
 	if len(chirp.Body) > maxChirpLength {
 		utils.RespondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
 		return
 	}
 
-	chi, err := h.cfg.DbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleanBody(chirp.Body),
-		UserID: userId,
+	if chirp.RechirpOf != "" && (chirp.QuoteOf != "" || chirp.ReplyTo != "" || chirp.Body != "") {
+		utils.RespondWithError(w, http.StatusBadRequest, "A rechirp cannot have a body, quote or reply", nil)
+		return
+	}
+	if chirp.QuoteOf != "" && chirp.Body == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "A quote-chirp needs a body", nil)
+		return
+	}
+
+	rechirpOf := uuid.NullUUID{}
+	if chirp.RechirpOf != "" {
+		original, ok := h.resolveSharedChirp(w, r, chirp.RechirpOf)
+		if !ok {
+			return
+		}
+		rechirpOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	quoteOf := uuid.NullUUID{}
+	if chirp.QuoteOf != "" {
+		original, ok := h.resolveSharedChirp(w, r, chirp.QuoteOf)
+		if !ok {
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	moderated, ok := moderateBody(w, h.cfg, chirp.Body)
+	if !ok {
+		return
+	}
+
+	parentID := uuid.NullUUID{}
+	rootID := uuid.NullUUID{}
+	if chirp.ReplyTo != "" {
+		replyTo, err := uuid.Parse(chirp.ReplyTo)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid reply_to chirp ID", err)
+			return
+		}
+
+		parent, err := h.cfg.DbQueries.GetChirpByID(r.Context(), replyTo)
+		if err != nil || parent.DeletedAt.Valid || !canSeeChirp(r.Context(), parent) {
+			utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp to reply to", err)
+			return
+		}
+
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		// Replies share the root of their parent; a reply to a root chirp
+		// starts a thread rooted at that chirp.
+		rootID = parent.RootID
+		if !rootID.Valid {
+			rootID = parentID
+		}
+	}
+
+	tx, err := h.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.cfg.DbQueries.WithTx(tx)
+
+	chi, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:      moderated.Body,
+		UserID:    uuid.NullUUID{UUID: userId, Valid: true},
+		ParentID:  parentID,
+		RootID:    rootID,
+		RechirpOf: rechirpOf,
+		QuoteOf:   quoteOf,
 	})
+	if isUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "You have already rechirped this chirp", err)
+		return
+	}
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, models.Chirp{
-		ID:        chi.ID.String(),
-		CreatedAt: chi.CreatedAt.String(),
-		UpdatedAt: chi.UpdatedAt.String(),
-		Body:      chi.Body,
-		UserID:    chi.UserID.String(),
-	})
-}
+	if err := saveHashtags(r.Context(), qtx, chi.ID, chi.Body); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
+	}
+
+	if err := saveMentions(r.Context(), qtx, chi); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
+	}
 
-func cleanBody(body string) string {
-	targetWords := []string{"kerfuffle", "sharbert", "fornax"}
+	if err := saveFlags(r.Context(), qtx, chi.ID, moderated); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
+	}
 
-	// Replace each word (case-insensitively) with "****"
-	for _, word := range targetWords {
-		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
-		body = re.ReplaceAllString(body, "****")
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
 	}
 
-	return body
+	chirps, err := buildChirps(r.Context(), h.cfg.DbQueries, uuid.NullUUID{UUID: userId, Valid: true}, []database.Chirp{chi})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirp", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, chirps[0])
 }
 
 func (h *chirpHandler) GetChirps(w http.ResponseWriter, r *http.Request) {
@@ -93,6 +169,16 @@ func (h *chirpHandler) GetChirps(w http.ResponseWriter, r *http.Request) {
 		sort = "desc"
 	}
 
+	limit, cursor, err := parsePage(r.URL.Query(), sort)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	// Fetch one extra row to find out whether there is a next page.
+	pageSize := limit + 1
+	viewerID := middleware.ViewerID(r.Context())
+	includeHidden := viewerIsModerator(r)
+
 	authorId := r.URL.Query().Get("author_id")
 	if authorId != "" {
 		authorUUID, err := uuid.Parse(authorId)
@@ -102,49 +188,139 @@ func (h *chirpHandler) GetChirps(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if sort == "desc" {
-			dbChirps, err = h.cfg.DbQueries.GetChirpsByUserIDDesc(r.Context(), authorUUID)
+			dbChirps, err = h.cfg.DbQueries.GetChirpsByUserIDDesc(r.Context(), database.GetChirpsByUserIDDescParams{
+				UserID:          uuid.NullUUID{UUID: authorUUID, Valid: true},
+				ViewerID:        viewerID,
+				IncludeHidden:   includeHidden,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				PageSize:        pageSize,
+			})
 		} else {
-			dbChirps, err = h.cfg.DbQueries.GetChirpsByUserIDAsc(r.Context(), authorUUID)
+			dbChirps, err = h.cfg.DbQueries.GetChirpsByUserIDAsc(r.Context(), database.GetChirpsByUserIDAscParams{
+				UserID:          uuid.NullUUID{UUID: authorUUID, Valid: true},
+				ViewerID:        viewerID,
+				IncludeHidden:   includeHidden,
+				CursorCreatedAt: cursor.CreatedAt,
+				CursorID:        cursor.ID,
+				PageSize:        pageSize,
+			})
 		}
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirps", err)
 			return
 		}
 
-		chirps := convertDatabaseChirps(dbChirps)
-		utils.RespondWithJSON(w, http.StatusOK, chirps)
+		h.respondWithChirpPage(w, r, viewerID, dbChirps, limit)
 		return
 	}
 
 	if sort == "desc" {
-		dbChirps, err = h.cfg.DbQueries.GetChirpsDesc(r.Context())
-
+		dbChirps, err = h.cfg.DbQueries.GetChirpsDesc(r.Context(), database.GetChirpsDescParams{
+			ViewerID:        viewerID,
+			IncludeHidden:   includeHidden,
+			CursorCreatedAt: cursor.CreatedAt,
+			CursorID:        cursor.ID,
+			PageSize:        pageSize,
+		})
 	} else {
-		dbChirps, err = h.cfg.DbQueries.GetChirpsAsc(r.Context())
+		dbChirps, err = h.cfg.DbQueries.GetChirpsAsc(r.Context(), database.GetChirpsAscParams{
+			ViewerID:        viewerID,
+			IncludeHidden:   includeHidden,
+			CursorCreatedAt: cursor.CreatedAt,
+			CursorID:        cursor.ID,
+			PageSize:        pageSize,
+		})
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirps", err)
+		return
 	}
+
+	h.respondWithChirpPage(w, r, viewerID, dbChirps, limit)
+}
+
+func (h *chirpHandler) respondWithChirpPage(w http.ResponseWriter, r *http.Request, viewerID uuid.NullUUID, dbChirps []database.Chirp, limit int32) {
+	page, err := newChirpPage(r.Context(), h.cfg.DbQueries, viewerID, dbChirps, limit)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirps", err)
 		return
 	}
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
+
+// newChirpPage trims dbChirps to limit and sets the next cursor if rows were
+// left over.
+func newChirpPage(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, dbChirps []database.Chirp, limit int32) (models.ChirpPage, error) {
+	page := models.ChirpPage{}
+	if len(dbChirps) > int(limit) {
+		dbChirps = dbChirps[:limit]
+		last := dbChirps[len(dbChirps)-1]
+		page.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	chirps, err := buildChirps(ctx, q, viewerID, dbChirps)
+	if err != nil {
+		return models.ChirpPage{}, err
+	}
+	page.Chirps = chirps
+	return page, nil
+}
 
+// buildChirps converts dbChirps into response models, embedding referenced
+// chirps and, for an authenticated viewer, their likes.
+func buildChirps(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, dbChirps []database.Chirp) ([]models.Chirp, error) {
 	chirps := convertDatabaseChirps(dbChirps)
-	utils.RespondWithJSON(w, http.StatusOK, chirps)
+
+	if err := embedReferencedChirps(ctx, q, dbChirps, chirps); err != nil {
+		return nil, err
+	}
+
+	if viewerID.Valid {
+		if err := markLikedByMe(ctx, q, viewerID.UUID, chirps); err != nil {
+			return nil, err
+		}
+	}
+	return chirps, nil
 }
 
 func convertDatabaseChirps(dbChirps []database.Chirp) []models.Chirp {
 	chirps := make([]models.Chirp, len(dbChirps))
 	for i, dbChirp := range dbChirps {
-		chirps[i] = models.Chirp{
-			ID:        dbChirp.ID.String(),
-			CreatedAt: dbChirp.CreatedAt.String(),
-			UpdatedAt: dbChirp.UpdatedAt.String(),
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID.String(),
-		}
+		chirps[i] = convertDatabaseChirp(dbChirp)
 	}
 	return chirps
 }
 
+func convertDatabaseChirp(dbChirp database.Chirp) models.Chirp {
+	chirp := models.Chirp{
+		ID:        dbChirp.ID.String(),
+		CreatedAt: dbChirp.CreatedAt.String(),
+		UpdatedAt: dbChirp.UpdatedAt.String(),
+		Body:      dbChirp.Body,
+		UserID:    authorIDString(dbChirp.UserID),
+		Deleted:   dbChirp.DeletedAt.Valid,
+		LikeCount: int(dbChirp.LikeCount),
+		Hidden:    dbChirp.HiddenAt.Valid,
+	}
+	if dbChirp.ParentID.Valid {
+		chirp.ParentID = dbChirp.ParentID.UUID.String()
+	}
+	if dbChirp.RootID.Valid {
+		chirp.RootID = dbChirp.RootID.UUID.String()
+	}
+	return chirp
+}
+
+// authorIDString formats the author of a chirp. Tombstones of purged
+// accounts have none.
+func authorIDString(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return id.UUID.String()
+}
+
 func (h *chirpHandler) GetChirpByID(w http.ResponseWriter, r *http.Request) {
 
 	id := r.PathValue("chirpId")
@@ -157,33 +333,91 @@ func (h *chirpHandler) GetChirpByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dbChirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
-	if err != nil {
+	if err != nil || dbChirp.DeletedAt.Valid {
 		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, models.Chirp{
-		ID:        dbChirp.ID.String(),
-		CreatedAt: dbChirp.CreatedAt.String(),
-		UpdatedAt: dbChirp.UpdatedAt.String(),
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID.String(),
-	})
+	if !canSeeChirp(r.Context(), dbChirp) {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", nil)
+		return
+	}
+
+	chirps, err := buildChirps(r.Context(), h.cfg.DbQueries, middleware.ViewerID(r.Context()), []database.Chirp{dbChirp})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirp", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, chirps[0])
 }
 
 func (h *chirpHandler) DeleteChirp(w http.ResponseWriter, r *http.Request) {
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	userID := middleware.MustUser(r.Context()).ID
+
+	id := r.PathValue("chirpId")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	if chirp.UserID.UUID != userID {
+		utils.RespondWithError(w, http.StatusForbidden, "You are not allowed to delete this chirp", nil)
+		return
+	}
+
+	log.Printf("Deleting chirp with ID: %s", id)
+	tx, err := h.cfg.DB.BeginTx(r.Context(), nil)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
 		return
 	}
+	defer tx.Rollback()
 
-	userID, err := auth.ValidateJWT(bearerToken, string(h.cfg.Secret))
+	err = removeChirp(r.Context(), h.cfg.DbQueries.WithTx(tx), chirpID)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
 		return
 	}
 
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// removeChirp deletes a chirp along with its plain rechirps. Chirps that have
+// replies or quotes are replaced by a tombstone instead, so those still show
+// that they refer to a deleted chirp. q must be bound to a transaction.
+func removeChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID) error {
+	err := q.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	hasDependents, err := q.ChirpHasDependents(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+
+	if hasDependents {
+		return q.TombstoneChirp(ctx, chirpID)
+	}
+	return q.DeleteChirpByID(ctx, chirpID)
+}
+
+func (h *chirpHandler) UpdateChirp(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
 	id := r.PathValue("chirpId")
 	chirpID, err := uuid.Parse(id)
 	if err != nil {
@@ -192,22 +426,180 @@ func (h *chirpHandler) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 	}
 
 	chirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	if chirp.UserID.UUID != userID {
+		utils.RespondWithError(w, http.StatusForbidden, "You are not allowed to edit this chirp", nil)
+		return
+	}
+
+	if chirp.RechirpOf.Valid {
+		utils.RespondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited", nil)
+		return
+	}
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if len(params.Body) > maxChirpLength {
+		utils.RespondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+		return
+	}
+	// An empty body would make the chirp look deleted.
+	if strings.TrimSpace(params.Body) == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Chirp body cannot be empty", nil)
+		return
+	}
+
+	moderated, ok := moderateBody(w, h.cfg, params.Body)
+	if !ok {
+		return
+	}
+
+	tx, err := h.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.cfg.DbQueries.WithTx(tx)
+
+	_, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID: chirp.ID,
+		Body:    chirp.Body,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	updated, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		Body: moderated.Body,
+		ID:   chirp.ID,
+	})
 	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	if err := saveHashtags(r.Context(), qtx, updated.ID, updated.Body); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	if err := saveMentions(r.Context(), qtx, updated); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	if err := saveFlags(r.Context(), qtx, updated.ID, moderated); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	chirps, err := buildChirps(r.Context(), h.cfg.DbQueries, uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{updated})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirp", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+func (h *chirpHandler) GetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("chirpId")
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
+		return
+	}
+
+	dbChirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || dbChirp.DeletedAt.Valid {
 		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
 		return
 	}
+	if !canSeeChirp(r.Context(), dbChirp) {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", nil)
+		return
+	}
 
-	if chirp.UserID != userID {
-		utils.RespondWithError(w, http.StatusForbidden, "You are not allowed to delete this chirp", nil)
+	dbRevisions, err := h.cfg.DbQueries.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get revisions", err)
 		return
 	}
 
-	log.Printf("Deleting chirp with ID: %s", id)
-	err = h.cfg.DbQueries.DeleteChirpByID(r.Context(), chirpID)
+	revisions := make([]models.ChirpRevision, len(dbRevisions))
+	for i, rev := range dbRevisions {
+		revisions[i] = models.ChirpRevision{
+			ID:        rev.ID.String(),
+			CreatedAt: rev.CreatedAt.String(),
+			ChirpID:   rev.ChirpID.String(),
+			Body:      rev.Body,
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, revisions)
+}
+
+func (h *chirpHandler) GetChirpThread(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("chirpId")
+	chirpID, err := uuid.Parse(id)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+	rows, err := h.cfg.DbQueries.GetChirpThread(r.Context(), chirpID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get thread", err)
+		return
+	}
+	if len(rows) == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", nil)
+		return
+	}
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := buildChirps(r.Context(), h.cfg.DbQueries, middleware.ViewerID(r.Context()), dbChirps)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get thread", err)
+		return
+	}
+
+	// Hidden chirps the viewer may not see keep their place without a body.
+	thread := make([]models.ThreadChirp, len(rows))
+	for i, row := range rows {
+		if !canSeeChirp(r.Context(), row.Chirp) {
+			chirps[i].Body = ""
+		}
+		thread[i] = models.ThreadChirp{
+			Chirp: chirps[i],
+			Depth: int(row.Depth),
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, thread)
 }
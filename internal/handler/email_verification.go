@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/database"
+	chirpymail "github.com/onkelwolle/chirpy/internal/mail"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+const (
+	// emailVerificationTTL is how long the link in a verification email works.
+	emailVerificationTTL = 48 * time.Hour
+	// maxEmailLength is the longest address SMTP can deliver to.
+	maxEmailLength = 254
+)
+
+var errInvalidEmail = errors.New("email must be a plain address like name@example.com")
+
+// parseEmail checks that email is a bare address, without a display name.
+func parseEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if len(email) > maxEmailLength {
+		return "", errInvalidEmail
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return "", errInvalidEmail
+	}
+	return email, nil
+}
+
+// sendEmailVerification mails a link that confirms email for the user. It
+// replaces any earlier links, so only the latest address asked for can be
+// confirmed.
+func (u *usersHandler) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.DeleteEmailVerificationTokens(ctx, userID); err != nil {
+		return err
+	}
+	err = qtx.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: u.cfg.Clock.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	msg := chirpymail.Message{
+		To:      email,
+		Subject: "Confirm your email for Chirpy",
+		Body: fmt.Sprintf("To confirm this email address for your Chirpy account, open this link within %s:\n\n"+
+			"%s/verify-email?token=%s\n\n"+
+			"If you didn't sign up for Chirpy, you can ignore this email.\n",
+			emailVerificationTTL, u.cfg.PublicURL, url.QueryEscape(token)),
+	}
+	go func() {
+		if err := u.cfg.Mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("Couldn't send verification email: %s", err)
+		}
+	}()
+	return nil
+}
+
+// VerifyEmailPage serves the page verification emails link to. It sends the
+// token on to VerifyEmail.
+func (u *usersHandler) VerifyEmailPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	data := struct {
+		Token string
+	}{
+		Token: r.URL.Query().Get("token"),
+	}
+	if err := u.cfg.Templates.ExecuteTemplate(w, "verify_email.html", data); err != nil {
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// VerifyEmail confirms an address with a token from a verification email.
+// For an email change, this is when the new address replaces the old one.
+func (u *usersHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	verification, err := qtx.UseEmailVerificationToken(r.Context(), database.UseEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(params.Token),
+		Now:       u.cfg.Clock.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired verification token", nil)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+
+	user, err := qtx.GetUserByID(r.Context(), verification.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+	changed := user.Email != verification.Email
+
+	_, err = qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		Email: verification.Email,
+		ID:    verification.UserID,
+	})
+	if isUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "Email already taken", err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+
+	// Reset links already sent to the old address must not work anymore.
+	if changed {
+		if err := qtx.DeletePasswordResetTokens(r.Context(), verification.UserID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't verify email", err)
+		return
+	}
+
+	if changed {
+		log.Printf("User %s changed their email", verification.UserID)
+	}
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// ResendEmailVerification sends a new verification email for the caller's
+// current, unverified address.
+func (u *usersHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	user := middleware.MustUser(r.Context())
+	if user.EmailVerifiedAt.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "Email is already verified", nil)
+		return
+	}
+
+	if err := u.sendEmailVerification(r.Context(), user.ID, user.Email); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, nil)
+}
+
+// checkEmailChange decides what happens when a user asks for email. A new
+// address is returned as pending: it only replaces the current one once
+// confirmed. A change in case only is the same mailbox and returns "". An
+// address another account already uses gets a 409.
+func (u *usersHandler) checkEmailChange(w http.ResponseWriter, r *http.Request, current database.User, email string) (string, bool) {
+	if strings.EqualFold(email, current.Email) {
+		return "", true
+	}
+
+	_, err := u.cfg.DbQueries.GetUserByEmail(r.Context(), email)
+	if err == nil {
+		utils.RespondWithError(w, http.StatusConflict, "Email or handle already taken", nil)
+		return "", false
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return "", false
+	}
+	return email, true
+}
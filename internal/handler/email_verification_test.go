@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		expected string
+		err      error
+	}{
+		{name: "plain address", email: "bob@example.com", expected: "bob@example.com"},
+		{name: "surrounding space is trimmed", email: "  bob@example.com\n", expected: "bob@example.com"},
+		{name: "unicode address", email: "jöhn@exämple.com", expected: "jöhn@exämple.com"},
+		{
+			name:     "longest address",
+			email:    strings.Repeat("a", 64) + "@" + strings.Repeat("b", maxEmailLength-64-5) + ".com",
+			expected: strings.Repeat("a", 64) + "@" + strings.Repeat("b", maxEmailLength-64-5) + ".com",
+		},
+		{name: "empty", email: "", err: errInvalidEmail},
+		{name: "display name", email: "Bob <bob@example.com>", err: errInvalidEmail},
+		{name: "quoted local part", email: `"bob smith"@example.com`, err: errInvalidEmail},
+		{name: "comment", email: "bob(home)@example.com", err: errInvalidEmail},
+		{name: "no domain dot", email: "bob@localhost", err: errInvalidEmail},
+		{name: "missing @", email: "bob.example.com", err: errInvalidEmail},
+		{name: "two addresses", email: "bob@example.com, eve@example.com", err: errInvalidEmail},
+		{name: "header injection", email: "bob@example.com\r\nBcc: eve@example.com", err: errInvalidEmail},
+		{name: "over-long", email: strings.Repeat("a", 64) + "@" + strings.Repeat("b", maxEmailLength-64-4) + ".com", err: errInvalidEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEmail(tt.email)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
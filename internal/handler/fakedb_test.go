@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeQuery answers one sqlc query. Exec queries return nil rows.
+type fakeQuery func(args []driver.NamedValue) ([][]driver.Value, error)
+
+// fakeDB is a database/sql driver that answers queries by their sqlc name,
+// so handlers can be tested without a Postgres server. Queries it doesn't
+// know fail the test through their error.
+type fakeDB struct {
+	mu      sync.Mutex
+	queries map[string]fakeQuery
+}
+
+func newFakeDB(queries map[string]fakeQuery) *sql.DB {
+	return sql.OpenDB(&fakeDB{queries: queries})
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{db}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+func (db *fakeDB) run(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+	// sqlc starts every query with "-- name: <Name> :<kind>".
+	fields := strings.Fields(query)
+	if len(fields) < 3 || fields[1] != "name:" {
+		return nil, fmt.Errorf("fakedb: query without a name: %s", query)
+	}
+	name := fields[2]
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	q, ok := db.queries[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unexpected query %s", name)
+	}
+	return q(args)
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements aren't supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fakedb: transactions aren't supported")
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+// Columns only has to report how many there are; sqlc scans by position.
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+type followsHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewFollowsHandler(cfg *config.ApiConfig) *followsHandler {
+	return &followsHandler{cfg: cfg}
+}
+
+func (f *followsHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := f.followParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := f.cfg.DbQueries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (f *followsHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := f.followParams(w, r)
+	if !ok {
+		return
+	}
+
+	err := f.cfg.DbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// followParams resolves the caller and the user in the path, turning away
+// unknown users and attempts to follow yourself.
+func (f *followsHandler) followParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	followerID := middleware.MustUser(r.Context()).ID
+
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid user ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if followerID == followeeID {
+		utils.RespondWithError(w, http.StatusBadRequest, "You cannot follow yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	_, err = f.cfg.DbQueries.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return followerID, followeeID, true
+}
+
+func (f *followsHandler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, limit, offset, ok := f.listParams(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := f.cfg.DbQueries.GetFollowers(r.Context(), database.GetFollowersParams{
+		FolloweeID: userID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get followers", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, convertPublicUsers(users))
+}
+
+func (f *followsHandler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, limit, offset, ok := f.listParams(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := f.cfg.DbQueries.GetFollowing(r.Context(), database.GetFollowingParams{
+		FollowerID: userID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get followed users", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, convertPublicUsers(users))
+}
+
+func (f *followsHandler) listParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, int32, int32, bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid user ID", err)
+		return uuid.Nil, 0, 0, false
+	}
+
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return uuid.Nil, 0, 0, false
+	}
+	offset, err := parseOffset(r.URL.Query())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return uuid.Nil, 0, 0, false
+	}
+
+	return userID, limit, offset, true
+}
+
+func (f *followsHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	limit, cursor, err := parsePage(r.URL.Query(), "desc")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := f.cfg.DbQueries.GetTimeline(r.Context(), database.GetTimelineParams{
+		UserID:          uuid.NullUUID{UUID: userID, Valid: true},
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		PageSize:        limit + 1,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get timeline", err)
+		return
+	}
+
+	page, err := newChirpPage(r.Context(), f.cfg.DbQueries, uuid.NullUUID{UUID: userID, Valid: true}, dbChirps, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get timeline", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
+
+func convertPublicUsers(dbUsers []database.User) []models.PublicUser {
+	users := make([]models.PublicUser, len(dbUsers))
+	for i, user := range dbUsers {
+		users[i] = convertPublicUser(user)
+	}
+	return users
+}
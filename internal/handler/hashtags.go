@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	maxHashtagLength      = 64
+)
+
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+type hashtagsHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewHashtagsHandler(cfg *config.ApiConfig) *hashtagsHandler {
+	return &hashtagsHandler{cfg: cfg}
+}
+
+func (h *hashtagsHandler) GetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := normalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid hashtag", nil)
+		return
+	}
+
+	limit, cursor, err := parsePage(r.URL.Query(), "desc")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := h.cfg.DbQueries.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+		Tag:             tag,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		PageSize:        limit + 1,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirps", err)
+		return
+	}
+
+	page, err := newChirpPage(r.Context(), h.cfg.DbQueries, middleware.ViewerID(r.Context()), dbChirps, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get chirps", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
+
+func (h *hashtagsHandler) GetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxTrendingWindow {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid window", err)
+			return
+		}
+		window = d
+	}
+
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	rows, err := h.cfg.DbQueries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:    time.Now().UTC().Add(-window),
+		PageSize: limit,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get trending hashtags", err)
+		return
+	}
+
+	trending := make([]models.TrendingHashtag, len(rows))
+	for i, row := range rows {
+		trending[i] = models.TrendingHashtag{
+			Tag:        row.Tag,
+			ChirpCount: int(row.ChirpCount),
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, trending)
+}
+
+// extractHashtags returns the distinct normalized hashtags in body, in the
+// order they first appear.
+func extractHashtags(body string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := normalizeHashtag(match[1])
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func normalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if len(tag) > maxHashtagLength {
+		return ""
+	}
+	return tag
+}
+
+// saveHashtags relinks a chirp to the hashtags in its body, so an edit that
+// drops a tag also drops the chirp from that tag's listing.
+func saveHashtags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	if err := q.DeleteChirpHashtags(ctx, chirpID); err != nil {
+		return err
+	}
+
+	for _, tag := range extractHashtags(body) {
+		hashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirpID,
+			HashtagID: hashtag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "no hashtags",
+			body:     "just a chirp",
+			expected: nil,
+		},
+		{
+			name:     "tags are lowercased and deduplicated",
+			body:     "#Go is fun, #go is fast #golang",
+			expected: []string{"go", "golang"},
+		},
+		{
+			name:     "punctuation ends a tag",
+			body:     "shipping #release!",
+			expected: []string{"release"},
+		},
+		{
+			name:     "underscores are part of a tag",
+			body:     "#snake_case",
+			expected: []string{"snake_case"},
+		},
+		{
+			name:     "unicode tags",
+			body:     "#Über #日本 #café",
+			expected: []string{"über", "日本", "café"},
+		},
+		{
+			name:     "hash inside a word is not a tag",
+			body:     "issue a#1 and C#",
+			expected: nil,
+		},
+		{
+			name:     "html entities are not tags",
+			body:     "it&#39;s &#x27;",
+			expected: nil,
+		},
+		{
+			name:     "bare hash",
+			body:     "# #",
+			expected: nil,
+		},
+		{
+			name:     "tag at the length limit",
+			body:     "#" + strings.Repeat("a", maxHashtagLength),
+			expected: []string{strings.Repeat("a", maxHashtagLength)},
+		},
+		{
+			name:     "over-long tag is dropped",
+			body:     "#" + strings.Repeat("a", maxHashtagLength+1) + " #ok",
+			expected: []string{"ok"},
+		},
+		{
+			name:     "length limit counts bytes",
+			body:     "#" + strings.Repeat("ü", maxHashtagLength/2+1),
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractHashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+type jwksHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewJWKSHandler(cfg *config.ApiConfig) *jwksHandler {
+	return &jwksHandler{cfg: cfg}
+}
+
+// GetJWKS publishes the public keys access tokens can be verified with.
+// Verifiers should refetch when they see an unknown kid, so a short cache
+// lifetime is enough to pick up rotations.
+func (j *jwksHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.RespondWithJSON(w, http.StatusOK, j.cfg.Keys.JWKS())
+}
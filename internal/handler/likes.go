@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+func (h *chirpHandler) LikeChirp(w http.ResponseWriter, r *http.Request) {
+	h.setLike(w, r, true)
+}
+
+func (h *chirpHandler) UnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	h.setLike(w, r, false)
+}
+
+// setLike records or removes a like and adjusts the chirp's counter in the
+// same transaction. The counter only moves when the like row actually
+// changed, so repeated or concurrent requests cannot skew it.
+func (h *chirpHandler) setLike(w http.ResponseWriter, r *http.Request, like bool) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || !canSeeChirp(r.Context(), chirp) {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	tx, err := h.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update like", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.cfg.DbQueries.WithTx(tx)
+
+	var changed int64
+	if like {
+		changed, err = qtx.LikeChirp(r.Context(), database.LikeChirpParams{UserID: userID, ChirpID: chirpID})
+	} else {
+		changed, err = qtx.UnlikeChirp(r.Context(), database.UnlikeChirpParams{UserID: userID, ChirpID: chirpID})
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update like", err)
+		return
+	}
+
+	if changed > 0 {
+		if like {
+			err = qtx.IncrementChirpLikeCount(r.Context(), chirpID)
+		} else {
+			err = qtx.DecrementChirpLikeCount(r.Context(), chirpID)
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Could not update like", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not update like", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// markLikedByMe sets LikedByMe on every chirp the viewer has liked, including
+// the rechirped and quoted chirps embedded in them.
+func markLikedByMe(ctx context.Context, q *database.Queries, viewerID uuid.UUID, chirps []models.Chirp) error {
+	var marked []*models.Chirp
+	for i := range chirps {
+		marked = append(marked, &chirps[i])
+		if chirps[i].RechirpOf != nil {
+			marked = append(marked, chirps[i].RechirpOf)
+		}
+		if chirps[i].QuoteOf != nil {
+			marked = append(marked, chirps[i].QuoteOf)
+		}
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(marked))
+	for _, chirp := range marked {
+		id, err := uuid.Parse(chirp.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	liked, err := q.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+
+	likedSet := make(map[string]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id.String()] = true
+	}
+	for _, chirp := range marked {
+		chirp.LikedByMe = likedSet[chirp.ID]
+	}
+	return nil
+}
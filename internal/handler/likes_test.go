@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/models"
+)
+
+func TestMarkLikedByMeMarksEmbeddedChirps(t *testing.T) {
+	liked := uuid.New()
+	db := newFakeDB(map[string]fakeQuery{
+		"GetLikedChirpIDs": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			return [][]driver.Value{{liked.String()}}, nil
+		},
+	})
+
+	chirps := []models.Chirp{
+		{ID: uuid.NewString(), QuoteOf: &models.Chirp{ID: liked.String()}},
+		{ID: uuid.NewString(), RechirpOf: &models.Chirp{ID: liked.String()}},
+		{ID: liked.String()},
+	}
+	if err := markLikedByMe(context.Background(), database.New(db), uuid.New(), chirps); err != nil {
+		t.Fatal(err)
+	}
+
+	if chirps[0].LikedByMe || chirps[1].LikedByMe {
+		t.Error("expected chirps embedding a liked chirp to stay unliked")
+	}
+	if !chirps[0].QuoteOf.LikedByMe {
+		t.Error("expected quoted chirp to be liked")
+	}
+	if !chirps[1].RechirpOf.LikedByMe {
+		t.Error("expected rechirped chirp to be liked")
+	}
+	if !chirps[2].LikedByMe {
+		t.Error("expected liked chirp to be liked")
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// loginFailureWindow is how long a failed login counts against its email
+// and IP address.
+const loginFailureWindow = 24 * time.Hour
+
+func emailLoginKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginLock answers 429 with Retry-After while the email or IP address
+// of a login is locked out.
+func (u *usersHandler) checkLoginLock(w http.ResponseWriter, r *http.Request, email string) bool {
+	failures, err := u.cfg.DbQueries.GetLoginFailures(r.Context(), []string{emailLoginKey(email), ipLoginKey(clientIP(r))})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
+		return false
+	}
+
+	now := u.cfg.Clock.Now()
+	var lockedUntil time.Time
+	for _, f := range failures {
+		if f.LockedUntil.Valid && f.LockedUntil.Time.After(lockedUntil) {
+			lockedUntil = f.LockedUntil.Time
+		}
+	}
+	if !lockedUntil.After(now) {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(lockedUntil.Sub(now).Seconds()))
+	w.Header().Set("Retry-After", fmt.Sprint(retryAfter))
+	utils.RespondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+	return false
+}
+
+// recordLoginFailure counts a failed login against its email and IP
+// address and locks them out as their policies say.
+func (u *usersHandler) recordLoginFailure(r *http.Request, email string) {
+	ip := clientIP(r)
+	u.recordFailure(r, emailLoginKey(email), u.cfg.AccountLockout)
+	u.recordFailure(r, ipLoginKey(ip), u.cfg.IPLockout)
+}
+
+func (u *usersHandler) recordFailure(r *http.Request, key string, policy auth.LockoutPolicy) {
+	now := u.cfg.Clock.Now()
+	failures, err := u.cfg.DbQueries.RecordLoginFailure(r.Context(), database.RecordLoginFailureParams{
+		Key:         key,
+		Now:         now,
+		ResetBefore: now.Add(-loginFailureWindow),
+	})
+	if err != nil {
+		log.Printf("Couldn't record failed login for %s: %s", key, err)
+		return
+	}
+
+	delay := policy.Delay(int(failures))
+	if delay == 0 {
+		return
+	}
+	err = u.cfg.DbQueries.LockLogin(r.Context(), database.LockLoginParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: now.Add(delay), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Couldn't lock login for %s: %s", key, err)
+		return
+	}
+	if int(failures) >= policy.Threshold {
+		log.Printf("SECURITY: %s locked out for %s after %d failed logins", key, delay, failures)
+	}
+}
+
+// confirmPassword guards sensitive changes behind the caller's current
+// password. Wrong passwords count toward the same lockout as logins.
+func (u *usersHandler) confirmPassword(w http.ResponseWriter, r *http.Request, user database.User, password string) bool {
+	if !u.checkLoginLock(w, r, user.Email) {
+		return false
+	}
+	if err := auth.ComparePassword(user.HashedPassword, password); err != nil {
+		u.recordLoginFailure(r, user.Email)
+		utils.RespondWithError(w, http.StatusForbidden, "Password is incorrect", err)
+		return false
+	}
+	return true
+}
+
+// clearLoginFailures forgets the failures of an email after a successful
+// login. The IP address keeps its count, so one valid account can't be
+// used to reset it while guessing passwords for others.
+func (u *usersHandler) clearLoginFailures(r *http.Request, email string) {
+	if err := u.cfg.DbQueries.ClearLoginFailures(r.Context(), emailLoginKey(email)); err != nil {
+		log.Printf("Couldn't clear failed logins: %s", err)
+	}
+}
+
+// UnlockUser lifts a lockout on a user's account.
+func (u *usersHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid user ID", err)
+		return
+	}
+
+	user, err := u.cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Couldn't get user", err)
+		return
+	}
+
+	if err := u.cfg.DbQueries.ClearLoginFailures(r.Context(), emailLoginKey(user.Email)); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't unlock user", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
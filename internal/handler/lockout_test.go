@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// loginFailureQueries keeps the login_failures table in memory.
+func loginFailureQueries(now time.Time) map[string]fakeQuery {
+	failures := map[string]*database.LoginFailure{}
+	return map[string]fakeQuery{
+		"GetLoginFailures": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, f := range failures {
+				var lockedUntil driver.Value
+				if f.LockedUntil.Valid {
+					lockedUntil = f.LockedUntil.Time
+				}
+				rows = append(rows, []driver.Value{f.Key, int64(f.Failures), f.LastFailureAt, lockedUntil})
+			}
+			return rows, nil
+		},
+		"RecordLoginFailure": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			key := args[0].Value.(string)
+			f, ok := failures[key]
+			if !ok {
+				f = &database.LoginFailure{Key: key}
+				failures[key] = f
+			}
+			f.Failures++
+			f.LastFailureAt = now
+			return [][]driver.Value{{int64(f.Failures)}}, nil
+		},
+		"LockLogin": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			f := failures[args[0].Value.(string)]
+			f.LockedUntil.Time, f.LockedUntil.Valid = args[1].Value.(time.Time)
+			return nil, nil
+		},
+	}
+}
+
+func userRow(user database.User) []driver.Value {
+	return []driver.Value{
+		user.ID.String(), user.CreatedAt, user.UpdatedAt, user.Email, user.HashedPassword,
+		user.IsChirpyRed, nil, user.Role, nil, "", "", "", "", "", nil,
+	}
+}
+
+func TestPatchCurrentUserLocksOutWrongPasswords(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          "walt@example.com",
+		HashedPassword: hash,
+		Role:           auth.RoleUser,
+	}
+
+	queries := loginFailureQueries(now)
+	queries["GetUserByID"] = func(args []driver.NamedValue) ([][]driver.Value, error) {
+		return [][]driver.Value{userRow(user)}, nil
+	}
+	policy := auth.LockoutPolicy{FreeAttempts: 3, BaseDelay: time.Minute, Threshold: 10, LockoutDuration: time.Hour}
+	cfg := &config.ApiConfig{
+		DbQueries:      database.New(newFakeDB(queries)),
+		Keys:           auth.NewKeySet([]byte("mysecret"), time.Hour),
+		Clock:          fixedClock(now),
+		AccountLockout: policy,
+		IPLockout:      policy,
+	}
+	token, err := cfg.Keys.MakeJWT(user.ID, uuid.New(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := middleware.RequireAuth(cfg, auth.ScopeProfileWrite)(http.HandlerFunc(NewUsersHandler(cfg).PatchCurrentUser))
+
+	patch := func(currentPassword string) *httptest.ResponseRecorder {
+		body := `{"password": "battery staple", "current_password": "` + currentPassword + `"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/me", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < policy.FreeAttempts; i++ {
+		if rec := patch("wrong"); rec.Code != http.StatusForbidden {
+			t.Fatalf("attempt %d: expected 403, got %d", i+1, rec.Code)
+		}
+	}
+
+	// Once locked, even the right password is turned away.
+	rec := patch("correct horse")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected Retry-After 60, got %q", rec.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+var (
+	handlePattern  = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_]+)`)
+
+	errInvalidHandle = errors.New("handle must be 3-30 characters of letters, digits or underscores")
+)
+
+// parseHandle normalizes an optional handle from a request. An empty handle
+// means none was supplied.
+func parseHandle(handle string) (sql.NullString, error) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if handle == "" {
+		return sql.NullString{}, nil
+	}
+	if !handlePattern.MatchString(handle) {
+		return sql.NullString{}, errInvalidHandle
+	}
+	return sql.NullString{String: handle, Valid: true}, nil
+}
+
+// extractMentions returns the distinct normalized handles mentioned in body.
+func extractMentions(body string) []string {
+	seen := map[string]bool{}
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(match[1])
+		if !handlePattern.MatchString(handle) || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// saveMentions replaces the users mentioned by a chirp with the ones in its
+// body. Handles that don't belong to anyone and the author mentioning
+// themselves are ignored. Pass the transaction that writes the body, so an
+// edit can't leave stale mentions behind.
+func saveMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	handles := extractMentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.ID == chirp.UserID.UUID {
+			continue
+		}
+		err := q.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *usersHandler) GetMyMentions(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	limit, cursor, err := parsePage(r.URL.Query(), "desc")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := u.cfg.DbQueries.GetMentionedChirps(r.Context(), database.GetMentionedChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorID:        cursor.ID,
+		PageSize:        limit + 1,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get mentions", err)
+		return
+	}
+
+	page, err := newChirpPage(r.Context(), u.cfg.DbQueries, uuid.NullUUID{UUID: userID, Valid: true}, dbChirps, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get mentions", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHandle(t *testing.T) {
+	tests := []struct {
+		name     string
+		handle   string
+		expected sql.NullString
+		err      error
+	}{
+		{
+			name:     "empty means none",
+			handle:   "",
+			expected: sql.NullString{},
+		},
+		{
+			name:     "lowercased and @ stripped",
+			handle:   "@Bob_42",
+			expected: sql.NullString{String: "bob_42", Valid: true},
+		},
+		{
+			name:     "shortest handle",
+			handle:   "abc",
+			expected: sql.NullString{String: "abc", Valid: true},
+		},
+		{
+			name:     "longest handle",
+			handle:   strings.Repeat("a", 30),
+			expected: sql.NullString{String: strings.Repeat("a", 30), Valid: true},
+		},
+		{name: "too short", handle: "ab", err: errInvalidHandle},
+		{name: "over-long", handle: strings.Repeat("a", 31), err: errInvalidHandle},
+		{name: "unicode letters", handle: "jöhn", err: errInvalidHandle},
+		{name: "fullwidth letters", handle: "ｂｏｂ", err: errInvalidHandle},
+		{name: "whitespace", handle: " bob ", err: errInvalidHandle},
+		{name: "punctuation", handle: "bob.smith", err: errInvalidHandle},
+		{name: "double @", handle: "@@bob", err: errInvalidHandle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHandle(tt.handle)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "no mentions",
+			body:     "just a chirp",
+			expected: nil,
+		},
+		{
+			name:     "lowercased and deduplicated",
+			body:     "@Alice meet @bob_1, @alice!",
+			expected: []string{"alice", "bob_1"},
+		},
+		{
+			name:     "email addresses are not mentions",
+			body:     "mail bob@example.com",
+			expected: nil,
+		},
+		{
+			name:     "double @ is not a mention",
+			body:     "@@alice",
+			expected: nil,
+		},
+		{
+			name:     "too short",
+			body:     "@ab",
+			expected: nil,
+		},
+		{
+			name:     "over-long handle is dropped, not truncated",
+			body:     "@" + strings.Repeat("a", 31) + " @bob",
+			expected: []string{"bob"},
+		},
+		{
+			name:     "unicode handle is dropped, not truncated",
+			body:     "@abcü @jöhn @bob",
+			expected: []string{"bob"},
+		},
+		{
+			name:     "mention after unicode text",
+			body:     "grüße @alice",
+			expected: []string{"alice"},
+		},
+		{
+			name:     "mention inside a word is not a mention",
+			body:     "über@alice",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -41,7 +41,12 @@ func (m *metricsHandler) ResetMetricsHandler(w http.ResponseWriter, r *http.Requ
 		utils.RespondWithError(w, http.StatusForbidden, "Reset not allowed", nil)
 		return
 	}
-	err := m.cfg.DbQueries.DeleteUsers(r.Context())
+	err := m.cfg.DbQueries.DeleteChirps(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not reset chirps", err)
+		return
+	}
+	err = m.cfg.DbQueries.DeleteUsers(r.Context())
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Could not reset users", err)
 	}
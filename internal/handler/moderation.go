@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/moderation"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+type moderationHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewModerationHandler(cfg *config.ApiConfig) *moderationHandler {
+	return &moderationHandler{cfg: cfg}
+}
+
+func (m *moderationHandler) GetBannedWords(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, http.StatusOK, m.cfg.BannedWords.Words())
+}
+
+func (m *moderationHandler) AddBannedWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Word string `json:"word"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	word := strings.ToLower(strings.TrimSpace(params.Word))
+	if word == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Word cannot be empty", nil)
+		return
+	}
+
+	err = m.cfg.DbQueries.AddBannedWord(r.Context(), word)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't add banned word", err)
+		return
+	}
+	m.cfg.BannedWords.Add(word)
+
+	utils.RespondWithJSON(w, http.StatusCreated, m.cfg.BannedWords.Words())
+}
+
+func (m *moderationHandler) DeleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	word := strings.ToLower(strings.TrimSpace(r.PathValue("word")))
+
+	err := m.cfg.DbQueries.DeleteBannedWord(r.Context(), word)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't delete banned word", err)
+		return
+	}
+	m.cfg.BannedWords.Remove(word)
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// moderateBody runs body through the moderation chain and answers 422 if the
+// chirp is rejected.
+func moderateBody(w http.ResponseWriter, cfg *config.ApiConfig, body string) (moderation.Result, bool) {
+	result := cfg.Moderation.Check(body)
+	if result.Rejected {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Chirp rejected: "+strings.Join(result.Reasons, ", "), nil)
+		return result, false
+	}
+	return result, true
+}
+
+// saveFlags queues a chirp for review if moderation flagged it.
+func saveFlags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, result moderation.Result) error {
+	if !result.Flagged {
+		return nil
+	}
+	for _, reason := range result.Reasons {
+		err := q.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ChirpID: chirpID,
+			Reason:  reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
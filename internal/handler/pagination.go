@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// Encoded cursors are about 90 characters long.
+	maxCursorLength = 128
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor marks the last row of a page. Rows are ordered by created_at
+// and then id, so chirps created in the same instant still page stably.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c pageCursor) encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	if len(s) > maxCursorLength {
+		return pageCursor{}, errInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	return pageCursor{CreatedAt: t, ID: u}, nil
+}
+
+// startCursor returns a cursor positioned before the first row for the given
+// sort order, so the first page can use the same keyset query as later pages.
+func startCursor(sort string) pageCursor {
+	if sort == "desc" {
+		return pageCursor{
+			CreatedAt: time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+			ID:        uuid.Max,
+		}
+	}
+	return pageCursor{
+		CreatedAt: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		ID:        uuid.Nil,
+	}
+}
+
+// parsePage reads the limit and cursor query parameters.
+func parsePage(query url.Values, sort string) (int32, pageCursor, error) {
+	limit, err := parseLimit(query)
+	if err != nil {
+		return 0, pageCursor{}, err
+	}
+
+	cursor := startCursor(sort)
+	if c := query.Get("cursor"); c != "" {
+		cursor, err = decodeCursor(c)
+		if err != nil {
+			return 0, pageCursor{}, err
+		}
+	}
+
+	return limit, cursor, nil
+}
+
+// parseLimit reads the limit query parameter, falling back to the default
+// page size and capping it at maxPageSize.
+func parseLimit(query url.Values) (int32, error) {
+	l := query.Get("limit")
+	if l == "" {
+		return defaultPageSize, nil
+	}
+
+	n, err := strconv.Atoi(l)
+	if err != nil || n < 1 {
+		return 0, errors.New("invalid limit")
+	}
+	return int32(min(n, maxPageSize)), nil
+}
+
+// parseOffset reads the offset query parameter used by ranked listings that
+// cannot be paged by cursor.
+func parseOffset(query url.Values) (int32, error) {
+	o := query.Get("offset")
+	if o == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(o)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid offset")
+	}
+	return int32(n), nil
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor pageCursor
+	}{
+		{
+			name:   "nanosecond timestamp",
+			cursor: pageCursor{CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC), ID: uuid.New()},
+		},
+		{
+			name:   "non-UTC timestamp",
+			cursor: pageCursor{CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("CEST", 2*60*60)), ID: uuid.New()},
+		},
+		{
+			name:   "start of the ascending order",
+			cursor: startCursor("asc"),
+		},
+		{
+			name:   "start of the descending order",
+			cursor: startCursor("desc"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.cursor.encode()
+			if len(encoded) > maxCursorLength {
+				t.Fatalf("expected at most %d characters, got %d", maxCursorLength, len(encoded))
+			}
+			got, err := decodeCursor(encoded)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !got.CreatedAt.Equal(tt.cursor.CreatedAt) || got.ID != tt.cursor.ID {
+				t.Errorf("expected %v, got %v", tt.cursor, got)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	id := uuid.New().String()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!not-base64!!"},
+		{name: "missing separator", cursor: encode("2024-05-06T07:08:09Z" + id)},
+		{name: "bad timestamp", cursor: encode("yesterday|" + id)},
+		{name: "bad id", cursor: encode("2024-05-06T07:08:09Z|not-a-uuid")},
+		{name: "empty parts", cursor: encode("|")},
+		{name: "extra separator", cursor: encode("2024-05-06T07:08:09Z|" + id + "|x")},
+		{name: "unicode", cursor: encode("２０２４-05-06T07:08:09Z|" + id)},
+		{name: "over-long", cursor: encode("2024-05-06T07:08:09Z|" + id + strings.Repeat(" ", 200))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor); !errors.Is(err, errInvalidCursor) {
+				t.Errorf("expected %v, got %v", errInvalidCursor, err)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       string
+		expected    int32
+		expectError bool
+	}{
+		{name: "default", limit: "", expected: defaultPageSize},
+		{name: "explicit", limit: "5", expected: 5},
+		{name: "capped", limit: "1000", expected: maxPageSize},
+		{name: "zero", limit: "0", expectError: true},
+		{name: "negative", limit: "-1", expectError: true},
+		{name: "not a number", limit: "ten", expectError: true},
+		{name: "overflows int", limit: "99999999999999999999", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.limit != "" {
+				query.Set("limit", tt.limit)
+			}
+			got, err := parseLimit(query)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
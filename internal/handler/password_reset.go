@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/mail"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// passwordResetTTL is how long the link in a reset email works.
+const passwordResetTTL = time.Hour
+
+// RequestPasswordReset mails a reset link to the account with the given
+// email. It always answers 202, so it can't be used to find out which
+// emails have an account.
+func (u *usersHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if err := u.sendPasswordReset(r.Context(), params.Email); err != nil {
+		log.Printf("Couldn't start password reset: %s", err)
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, nil)
+}
+
+// sendPasswordReset replaces any earlier reset tokens of the user with a
+// new one and mails it. Unknown emails are silently ignored.
+func (u *usersHandler) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := u.cfg.DbQueries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.DeletePasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	err = qtx.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: u.cfg.Clock.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your Chirpy account.\n\n"+
+			"To choose a new password, open this link within %s:\n\n%s/reset-password?token=%s\n\n"+
+			"If this wasn't you, you can ignore this email.\n",
+			passwordResetTTL, u.cfg.PublicURL, url.QueryEscape(token)),
+	}
+	// Sending happens in the background so a slow mail server doesn't make
+	// known emails answer noticeably slower than unknown ones.
+	go func() {
+		if err := u.cfg.Mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("Couldn't send password reset email: %s", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPasswordPage serves the page reset emails link to. It sends the token
+// and the new password on to ConfirmPasswordReset.
+func (u *usersHandler) ResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	data := struct {
+		Token string
+	}{
+		Token: r.URL.Query().Get("token"),
+	}
+	if err := u.cfg.Templates.ExecuteTemplate(w, "reset_password.html", data); err != nil {
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// ConfirmPasswordReset sets a new password with a token from a reset email
+// and logs the user out everywhere.
+func (u *usersHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Password == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Password is required", nil)
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	userID, err := qtx.UsePasswordResetToken(r.Context(), database.UsePasswordResetTokenParams{
+		TokenHash: auth.HashToken(params.Token),
+		Now:       u.cfg.Clock.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token", nil)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		HashedPassword: hashedPassword,
+		ID:             userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	_, err = qtx.RevokeUserSessions(r.Context(), database.RevokeUserSessionsParams{UserID: userID})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	// Whoever owns the inbox is the owner of the account, so a lockout
+	// from someone guessing the old password shouldn't keep them out.
+	if user, err := u.cfg.DbQueries.GetUserByID(r.Context(), userID); err == nil {
+		u.clearLoginFailures(r, user.Email)
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// Maximum lengths of profile fields, in characters.
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxURLLength         = 300
+)
+
+// GetUser returns the public profile of a user, by ID or by handle.
+func (u *usersHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	var user database.User
+	var err error
+	if userID, parseErr := uuid.Parse(r.PathValue("id")); parseErr == nil {
+		user, err = u.cfg.DbQueries.GetUserByID(r.Context(), userID)
+	} else {
+		user, err = u.getUserByHandle(r, r.PathValue("id"))
+	}
+	// Accounts waiting to be purged are gone as far as others can tell.
+	if err == nil && user.DeletionRequestedAt.Valid {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, convertPublicUser(user))
+}
+
+func (u *usersHandler) getUserByHandle(r *http.Request, handle string) (database.User, error) {
+	parsed, err := parseHandle(handle)
+	if err != nil || !parsed.Valid {
+		return database.User{}, sql.ErrNoRows
+	}
+	users, err := u.cfg.DbQueries.GetUsersByHandles(r.Context(), []string{parsed.String})
+	if err != nil {
+		return database.User{}, err
+	}
+	if len(users) == 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	return users[0], nil
+}
+
+// parseProfileText trims a free-text profile field and checks its length.
+// Control characters are refused, except for line breaks in the bio.
+func parseProfileText(field, value string, maxLength int, multiline bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if n := utf8.RuneCountInString(value); n > maxLength {
+		return "", fmt.Errorf("%s must be at most %d characters, got %d", field, maxLength, n)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) && !(multiline && r == '\n') {
+			return "", fmt.Errorf("%s must not contain control characters", field)
+		}
+	}
+	return value, nil
+}
+
+// parseProfileURL accepts an absolute http or https URL, or "" to remove it.
+func parseProfileURL(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+		return "", fmt.Errorf("%s must be an http or https URL", field)
+	}
+	// Escaping can make the stored URL longer than what was sent.
+	normalized := parsed.String()
+	if len(normalized) > maxURLLength {
+		return "", fmt.Errorf("%s must be at most %d characters", field, maxURLLength)
+	}
+	return normalized, nil
+}
+
+func convertUser(user database.User) models.User {
+	return models.User{
+		Id:            user.ID.String(),
+		CreatedAt:     user.CreatedAt.String(),
+		UpdatedAt:     user.UpdatedAt.String(),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		IsChirpyRed:   user.IsChirpyRed,
+		Handle:        user.Handle.String,
+		Profile:       convertProfile(user),
+		Role:          user.Role,
+	}
+}
+
+// convertPublicUser leaves out everything only the user themselves may see,
+// like their email.
+func convertPublicUser(user database.User) models.PublicUser {
+	return models.PublicUser{
+		Id:          user.ID.String(),
+		CreatedAt:   user.CreatedAt.String(),
+		IsChirpyRed: user.IsChirpyRed,
+		Handle:      user.Handle.String,
+		Profile:     convertProfile(user),
+	}
+}
+
+func convertProfile(user database.User) models.Profile {
+	return models.Profile{
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Location:    user.Location,
+		Website:     user.Website,
+		AvatarURL:   user.AvatarUrl,
+	}
+}
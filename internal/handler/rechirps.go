@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// resolveSharedChirp looks up the chirp being rechirped or quoted. Sharing a
+// rechirp shares its original instead, so references never chain. Missing
+// and deleted chirps, and hidden ones the viewer may not see, get a 404.
+func (h *chirpHandler) resolveSharedChirp(w http.ResponseWriter, r *http.Request, id string) (database.Chirp, bool) {
+	chirpID, err := uuid.Parse(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return database.Chirp{}, false
+	}
+
+	chirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err == nil && chirp.RechirpOf.Valid {
+		chirp, err = h.cfg.DbQueries.GetChirpByID(r.Context(), chirp.RechirpOf.UUID)
+	}
+	if err != nil || chirp.DeletedAt.Valid || !canSeeChirp(r.Context(), chirp) {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp to share", err)
+		return database.Chirp{}, false
+	}
+
+	return chirp, true
+}
+
+// embedReferencedChirps fills in RechirpOf and QuoteOf on chirps, which must
+// line up index by index with dbChirps. Deleted originals, and hidden ones
+// the viewer may not see, are embedded as tombstones so clients can tell the
+// reference is gone.
+func embedReferencedChirps(ctx context.Context, q *database.Queries, dbChirps []database.Chirp, chirps []models.Chirp) error {
+	var ids []uuid.UUID
+	for _, dbChirp := range dbChirps {
+		if dbChirp.RechirpOf.Valid {
+			ids = append(ids, dbChirp.RechirpOf.UUID)
+		}
+		if dbChirp.QuoteOf.Valid {
+			ids = append(ids, dbChirp.QuoteOf.UUID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	referenced, err := q.GetChirpsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uuid.UUID]models.Chirp, len(referenced))
+	for _, ref := range referenced {
+		chirp := convertDatabaseChirp(ref)
+		if !canSeeChirp(ctx, ref) {
+			chirp.Body = ""
+		}
+		byID[ref.ID] = chirp
+	}
+
+	for i, dbChirp := range dbChirps {
+		if dbChirp.RechirpOf.Valid {
+			if ref, ok := byID[dbChirp.RechirpOf.UUID]; ok {
+				chirps[i].RechirpOf = &ref
+			}
+		}
+		if dbChirp.QuoteOf.Valid {
+			if ref, ok := byID[dbChirp.QuoteOf.UUID]; ok {
+				chirps[i].QuoteOf = &ref
+			}
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
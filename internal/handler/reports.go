@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+const (
+	reportStatusOpen      = "open"
+	reportStatusDismissed = "dismissed"
+	reportStatusActioned  = "actioned"
+
+	maxReportDetailsLength = 500
+)
+
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"misinformation": true,
+	"violence":       true,
+	"other":          true,
+}
+
+func (h *chirpHandler) ReportChirp(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := h.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || !canSeeChirp(r.Context(), chirp) {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	type parameters struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !reportReasons[params.Reason] {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid report reason", nil)
+		return
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		utils.RespondWithError(w, http.StatusBadRequest, "Report details are too long", nil)
+		return
+	}
+
+	report, err := h.cfg.DbQueries.CreateReport(r.Context(), database.CreateReportParams{
+		ChirpID:    chirpID,
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if isUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "You have already reported this chirp", err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not create report", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, models.Report{
+		ID:        report.ID.String(),
+		CreatedAt: report.CreatedAt.String(),
+		ChirpID:   report.ChirpID.String(),
+		Reason:    report.Reason,
+		Details:   report.Details,
+		Status:    report.Status,
+	})
+}
+
+func (m *moderationHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	offset, err := parseOffset(r.URL.Query())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	rows, err := m.cfg.DbQueries.GetModerationQueue(r.Context(), database.GetModerationQueueParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not get reports", err)
+		return
+	}
+
+	reported := make([]models.ReportedChirp, len(rows))
+	for i, row := range rows {
+		reported[i] = models.ReportedChirp{
+			ChirpID:         row.ChirpID.String(),
+			Body:            row.Body,
+			UserID:          authorIDString(row.UserID),
+			Hidden:          row.HiddenAt.Valid,
+			ReportCount:     int(row.ReportCount),
+			Reasons:         row.Reasons,
+			Flags:           row.Flags,
+			FirstReportedAt: row.FirstReportedAt.String(),
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reported)
+}
+
+func (m *moderationHandler) DismissReports(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := m.reportedChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := m.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not dismiss reports", err)
+		return
+	}
+	defer tx.Rollback()
+
+	err = resolveReview(r.Context(), m.cfg.DbQueries.WithTx(tx), chirpID, reportStatusDismissed)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not dismiss reports", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not dismiss reports", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (m *moderationHandler) HideReportedChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := m.reportedChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := m.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not hide chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := m.cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.HideChirp(r.Context(), chirpID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not hide chirp", err)
+		return
+	}
+
+	err = resolveReview(r.Context(), qtx, chirpID, reportStatusActioned)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not hide chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not hide chirp", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (m *moderationHandler) DeleteReportedChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := m.reportedChirpID(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := m.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := m.cfg.DbQueries.WithTx(tx)
+
+	// Reports and flags on a tombstoned chirp survive the delete and need closing.
+	err = resolveReview(r.Context(), qtx, chirpID, reportStatusActioned)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+
+	log.Printf("Moderator deleting chirp with ID: %s", chirpID)
+	if err := removeChirp(r.Context(), qtx, chirpID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// resolveReview closes a chirp's open reports with status and clears its flags.
+func resolveReview(ctx context.Context, q *database.Queries, chirpID uuid.UUID, status string) error {
+	err := q.ResolveReports(ctx, database.ResolveReportsParams{
+		Status:  status,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		return err
+	}
+	return q.DeleteChirpFlags(ctx, chirpID)
+}
+
+func (m *moderationHandler) reportedChirpID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid chirp ID", err)
+		return uuid.Nil, false
+	}
+
+	chirp, err := m.cfg.DbQueries.GetChirpByID(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "Could not get chirp", err)
+		return uuid.Nil, false
+	}
+
+	return chirpID, true
+}
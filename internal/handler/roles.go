@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+type rolesHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewRolesHandler(cfg *config.ApiConfig) *rolesHandler {
+	return &rolesHandler{cfg: cfg}
+}
+
+func (rh *rolesHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !auth.ValidRole(params.Role) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+
+	rh.setRole(w, r, params.Role)
+}
+
+func (rh *rolesHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	rh.setRole(w, r, auth.RoleUser)
+}
+
+func (rh *rolesHandler) setRole(w http.ResponseWriter, r *http.Request, role string) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid user ID", err)
+		return
+	}
+
+	callerID := middleware.MustUser(r.Context()).ID
+	if callerID == userID {
+		utils.RespondWithError(w, http.StatusBadRequest, "You cannot change your own role", nil)
+		return
+	}
+
+	user, err := rh.cfg.DbQueries.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+		Role: role,
+		ID:   userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Couldn't update user", err)
+		return
+	}
+
+	log.Printf("User %s set role of user %s to %s", callerID, userID, role)
+	utils.RespondWithJSON(w, http.StatusOK, convertUser(user))
+}
+
+// viewerIsModerator reports whether the viewer may see chirps that have
+// been hidden by moderation.
+func viewerIsModerator(r *http.Request) bool {
+	return isModerator(r.Context())
+}
+
+// isModerator only counts logins. No token scope covers moderation, so a
+// moderator's personal access token sees what any other user would.
+func isModerator(ctx context.Context) bool {
+	user, ok := middleware.UserFromContext(ctx)
+	return ok && !middleware.UsesPersonalAccessToken(ctx) && auth.HasRole(user.Role, auth.RoleModerator)
+}
+
+// canSeeChirp reports whether the viewer may read a chirp. Hidden chirps
+// stay visible to their author and to moderators only.
+func canSeeChirp(ctx context.Context, chirp database.Chirp) bool {
+	if !chirp.HiddenAt.Valid {
+		return true
+	}
+	viewerID := middleware.ViewerID(ctx)
+	return (viewerID.Valid && viewerID == chirp.UserID) || isModerator(ctx)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+)
+
+func TestCanSeeHiddenChirp(t *testing.T) {
+	now := time.Now()
+	author := database.User{ID: uuid.New(), Email: "author@example.com", Role: auth.RoleUser}
+	other := database.User{ID: uuid.New(), Email: "other@example.com", Role: auth.RoleUser}
+	moderator := database.User{ID: uuid.New(), Email: "mod@example.com", Role: auth.RoleModerator}
+	users := map[string]database.User{}
+	for _, u := range []database.User{author, other, moderator} {
+		users[u.ID.String()] = u
+	}
+
+	pat, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newFakeDB(map[string]fakeQuery{
+		"GetUserByID": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			user, ok := users[args[0].Value.(string)]
+			if !ok {
+				return nil, nil
+			}
+			return [][]driver.Value{userRow(user)}, nil
+		},
+		"GetPersonalAccessTokenByHash": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			if args[0].Value != auth.HashToken(pat) {
+				return nil, nil
+			}
+			return [][]driver.Value{{
+				uuid.NewString(), moderator.ID.String(), "bot", auth.HashToken(pat),
+				"{" + auth.ScopeChirpsRead + "}", now, now.Add(time.Hour), nil, nil,
+			}}, nil
+		},
+		"TouchPersonalAccessToken": func(args []driver.NamedValue) ([][]driver.Value, error) {
+			return nil, nil
+		},
+	})
+	cfg := &config.ApiConfig{
+		DbQueries: database.New(db),
+		Keys:      auth.NewKeySet([]byte("mysecret"), time.Hour),
+	}
+
+	chirp := database.Chirp{
+		ID:       uuid.New(),
+		Body:     "hidden",
+		UserID:   uuid.NullUUID{UUID: author.ID, Valid: true},
+		HiddenAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	login := func(user database.User) string {
+		token, err := cfg.Keys.MakeJWT(user.ID, uuid.New(), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{name: "anonymous", expected: false},
+		{name: "author", token: login(author), expected: true},
+		{name: "other user", token: login(other), expected: false},
+		{name: "moderator login", token: login(moderator), expected: true},
+		{name: "moderator personal access token", token: pat, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			h := middleware.OptionalAuth(cfg, auth.ScopeChirpsRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = canSeeChirp(r.Context(), chirp)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
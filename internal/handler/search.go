@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+var errEmptySearchQuery = errors.New("search query is empty")
+
+func (h *chirpHandler) SearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	tsQuery, err := buildTSQuery(query.Get("q"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid search query", err)
+		return
+	}
+
+	limit, err := parseLimit(query)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	offset, err := parseOffset(query)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if a := query.Get("author_id"); a != "" {
+		authorUUID, err := uuid.Parse(a)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: authorUUID, Valid: true}
+	}
+
+	rows, err := h.cfg.DbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:      tsQuery,
+		AuthorID:   authorID,
+		PageSize:   limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not search chirps", err)
+		return
+	}
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := buildChirps(r.Context(), h.cfg.DbQueries, middleware.ViewerID(r.Context()), dbChirps)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Could not search chirps", err)
+		return
+	}
+
+	results := make([]models.ChirpSearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = models.ChirpSearchResult{
+			Chirp:   chirps[i],
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, results)
+}
+
+// buildTSQuery turns user input into a to_tsquery expression. Quoted text
+// becomes a phrase query, a trailing * marks a prefix term, and all parts are
+// ANDed together. Anything other than letters and digits is dropped so user
+// input can never inject tsquery operators.
+func buildTSQuery(q string) (string, error) {
+	var parts []string
+
+	for i, segment := range strings.Split(q, `"`) {
+		words := strings.Fields(segment)
+		// Odd segments sit between a pair of quotes.
+		if i%2 == 1 {
+			var phrase []string
+			for _, word := range words {
+				if lexeme := sanitizeLexeme(word); lexeme != "" {
+					phrase = append(phrase, lexeme)
+				}
+			}
+			if len(phrase) > 0 {
+				parts = append(parts, "("+strings.Join(phrase, " <-> ")+")")
+			}
+			continue
+		}
+
+		for _, word := range words {
+			lexeme := sanitizeLexeme(word)
+			if lexeme == "" {
+				continue
+			}
+			if strings.HasSuffix(word, "*") {
+				lexeme += ":*"
+			}
+			parts = append(parts, lexeme)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", errEmptySearchQuery
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+func sanitizeLexeme(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
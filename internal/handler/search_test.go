@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "single word",
+			query:    "Hello",
+			expected: "hello",
+		},
+		{
+			name:     "words are ANDed",
+			query:    "hello  world",
+			expected: "hello & world",
+		},
+		{
+			name:     "prefix term",
+			query:    "chirp*",
+			expected: "chirp:*",
+		},
+		{
+			name:     "quoted phrase",
+			query:    `"big red dog" cat`,
+			expected: "(big <-> red <-> dog) & cat",
+		},
+		{
+			name:     "unterminated quote is still a phrase",
+			query:    `cat "big dog`,
+			expected: "cat & (big <-> dog)",
+		},
+		{
+			name:     "tsquery operators are dropped",
+			query:    "a&b | !c <-> (d):*",
+			expected: "ab & c & d:*",
+		},
+		{
+			name:     "unicode letters are kept",
+			query:    "Über café",
+			expected: "über & café",
+		},
+		{
+			name:  "empty query",
+			query: "   ",
+			err:   errEmptySearchQuery,
+		},
+		{
+			name:  "only operators",
+			query: `& | ! "" *`,
+			err:   errEmptySearchQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTSQuery(tt.query)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
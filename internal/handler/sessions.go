@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// A session is one login: the family of refresh tokens that descends from
+// it through rotation. Its ID is the family ID, which access tokens carry in
+// their sid claim.
+type sessionsHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewSessionsHandler(cfg *config.ApiConfig) *sessionsHandler {
+	return &sessionsHandler{cfg: cfg}
+}
+
+func (s *sessionsHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+	currentID := middleware.SessionID(r.Context())
+
+	rows, err := s.cfg.DbQueries.GetUserSessions(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get sessions", err)
+		return
+	}
+
+	sessions := make([]models.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, models.Session{
+			ID:         row.FamilyID.String(),
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+			CreatedAt:  row.StartedAt.String(),
+			LastUsedAt: row.LastUsedAt.String(),
+			Current:    currentID.Valid && currentID.UUID == row.FamilyID,
+		})
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+func (s *sessionsHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid session ID", err)
+		return
+	}
+
+	revoked, err := s.cfg.DbQueries.RevokeUserSession(r.Context(), database.RevokeUserSessionParams{
+		UserID:   userID,
+		FamilyID: sessionID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
+		return
+	}
+	if revoked == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// RevokeAllSessions logs the user out everywhere. With except_current=true
+// the session making the request stays logged in.
+func (s *sessionsHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	var except uuid.NullUUID
+	if r.URL.Query().Get("except_current") == "true" {
+		except = middleware.SessionID(r.Context())
+		if !except.Valid {
+			utils.RespondWithError(w, http.StatusBadRequest, "Token is not tied to a session, log in again", nil)
+			return
+		}
+	}
+
+	revoked, err := s.cfg.DbQueries.RevokeUserSessions(r.Context(), database.RevokeUserSessionsParams{
+		UserID:         userID,
+		ExceptFamilyID: except,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, struct {
+		Revoked int64 `json:"revoked"`
+	}{
+		Revoked: revoked,
+	})
+}
+
+// clientIP is the address the request came from. X-Forwarded-For is
+// ignored since nothing guarantees a proxy in front of us that sets it.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+const (
+	defaultTokenExpiresInDays = 30
+	maxTokenExpiresInDays     = 365
+	maxTokenNameLength        = 100
+)
+
+type tokensHandler struct {
+	cfg *config.ApiConfig
+}
+
+func NewTokensHandler(cfg *config.ApiConfig) *tokensHandler {
+	return &tokensHandler{cfg: cfg}
+}
+
+func (t *tokensHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Name == "" || len(params.Name) > maxTokenNameLength {
+		utils.RespondWithError(w, http.StatusBadRequest, "Name must be 1 to 100 characters", nil)
+		return
+	}
+	if len(params.Scopes) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "At least one scope is required", nil)
+		return
+	}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unknown scope: "+scope, nil)
+			return
+		}
+	}
+	if params.ExpiresInDays == 0 {
+		params.ExpiresInDays = defaultTokenExpiresInDays
+	}
+	if params.ExpiresInDays < 0 || params.ExpiresInDays > maxTokenExpiresInDays {
+		utils.RespondWithError(w, http.StatusBadRequest, "expires_in_days must be between 1 and 365", nil)
+		return
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create token", err)
+		return
+	}
+
+	pat, err := t.cfg.DbQueries.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		Name:      params.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    params.Scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, params.ExpiresInDays),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create token", err)
+		return
+	}
+
+	resp := convertPersonalAccessToken(pat)
+	resp.Token = token
+	utils.RespondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (t *tokensHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	pats, err := t.cfg.DbQueries.GetPersonalAccessTokens(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get tokens", err)
+		return
+	}
+
+	tokens := make([]models.PersonalAccessToken, 0, len(pats))
+	for _, pat := range pats {
+		tokens = append(tokens, convertPersonalAccessToken(pat))
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (t *tokensHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Invalid token ID", err)
+		return
+	}
+
+	revoked, err := t.cfg.DbQueries.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't revoke token", err)
+		return
+	}
+	if revoked == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Token not found", nil)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func convertPersonalAccessToken(pat database.PersonalAccessToken) models.PersonalAccessToken {
+	token := models.PersonalAccessToken{
+		ID:        pat.ID.String(),
+		Name:      pat.Name,
+		Scopes:    pat.Scopes,
+		CreatedAt: pat.CreatedAt.String(),
+		ExpiresAt: pat.ExpiresAt.String(),
+	}
+	if pat.LastUsedAt.Valid {
+		token.LastUsedAt = pat.LastUsedAt.Time.String()
+	}
+	return token
+}
@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/middleware"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+const (
+	totpIssuer = "Chirpy"
+	// loginChallengeTTL is how long a password-verified login waits for its
+	// second factor.
+	loginChallengeTTL       = 5 * time.Minute
+	maxLoginChallengeTries  = 5
+	recoveryCodeCount       = 10
+	totpCodeLength          = 6
+	secondFactorInvalidCode = "Invalid code"
+)
+
+// EnrollTwoFactor creates a new TOTP secret. It isn't enforced until
+// ConfirmTwoFactor has seen a code generated from it. It takes the current
+// password, so a stolen access token can't enroll its own authenticator.
+func (u *usersHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := middleware.MustUser(r.Context())
+
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !u.confirmPassword(w, r, user, params.Password) {
+		return
+	}
+
+	totp, err := u.cfg.DbQueries.GetUserTOTP(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enroll two-factor authentication", err)
+		return
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enroll two-factor authentication", err)
+		return
+	}
+
+	err = u.cfg.DbQueries.UpsertUserTOTP(r.Context(), database.UpsertUserTOTPParams{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enroll two-factor authentication", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(secret, totpIssuer, user.Email),
+	})
+}
+
+// ConfirmTwoFactor turns on two-factor authentication and responds with
+// the recovery codes. They are only ever shown here.
+func (u *usersHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.MustUser(r.Context()).ID
+
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+
+	totp, err := u.cfg.DbQueries.GetUserTOTP(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Two-factor authentication is not being set up", err)
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		utils.RespondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	counter, ok := auth.ValidateTOTP(totp.Secret, code, u.cfg.Clock.Now())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, secondFactorInvalidCode, nil)
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	err = qtx.ConfirmUserTOTP(r.Context(), database.ConfirmUserTOTPParams{
+		UserID:      userID,
+		LastCounter: counter,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(r.Context(), qtx, userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't enable two-factor authentication", err)
+		return
+	}
+
+	respondWithRecoveryCodes(w, codes)
+}
+
+// DisableTwoFactor turns two-factor authentication off. It takes a current
+// code or a recovery code, so a stolen access token alone can't do it.
+func (u *usersHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := middleware.MustUser(r.Context())
+	userID := user.ID
+
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+	if !u.checkLoginLock(w, r, user.Email) {
+		return
+	}
+	if !u.checkSecondFactor(w, r, user, code) {
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.DeleteUserTOTP(r.Context(), userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't disable two-factor authentication", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, used or not.
+func (u *usersHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user := middleware.MustUser(r.Context())
+	userID := user.ID
+
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+	if !u.checkLoginLock(w, r, user.Email) {
+		return
+	}
+	if !u.checkSecondFactor(w, r, user, code) {
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(r.Context(), u.cfg.DbQueries.WithTx(tx), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create recovery codes", err)
+		return
+	}
+
+	respondWithRecoveryCodes(w, codes)
+}
+
+// startTwoFactorLogin answers a correct password for a user with two-factor
+// authentication with a challenge token instead of a session.
+func (u *usersHandler) startTwoFactorLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	challenge, err := auth.MakeRefreshToken()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
+		return
+	}
+
+	err = u.cfg.DbQueries.CreateLoginChallenge(r.Context(), database.CreateLoginChallengeParams{
+		TokenHash: auth.HashToken(challenge),
+		UserID:    user.ID,
+		ExpiresAt: u.cfg.Clock.Now().Add(loginChallengeTTL),
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+		ExpiresIn         int    `json:"expires_in"`
+	}{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+		ExpiresIn:         int(loginChallengeTTL.Seconds()),
+	})
+}
+
+// LoginTwoFactor finishes a login by exchanging the challenge token and a
+// TOTP or recovery code for a session.
+func (u *usersHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	challengeHash := auth.HashToken(params.ChallengeToken)
+	challenge, err := u.cfg.DbQueries.GetLoginChallenge(r.Context(), challengeHash)
+	if err != nil || challenge.ExpiresAt.Before(u.cfg.Clock.Now()) {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired challenge", err)
+		return
+	}
+
+	user, err := u.cfg.DbQueries.GetUserByID(r.Context(), challenge.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !u.checkLoginLock(w, r, user.Email) {
+		return
+	}
+
+	attempts, err := u.cfg.DbQueries.IncrementLoginChallengeAttempts(r.Context(), challengeHash)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
+		return
+	}
+	if attempts > maxLoginChallengeTries {
+		u.deleteLoginChallenge(r, challengeHash)
+		utils.RespondWithError(w, http.StatusUnauthorized, "Too many attempts, log in again", nil)
+		return
+	}
+
+	if !u.checkSecondFactor(w, r, user, params.Code) {
+		return
+	}
+	u.deleteLoginChallenge(r, challengeHash)
+
+	u.clearLoginFailures(r, user.Email)
+	u.issueTokens(w, r, user)
+}
+
+func (u *usersHandler) deleteLoginChallenge(r *http.Request, challengeHash string) {
+	if err := u.cfg.DbQueries.DeleteLoginChallenge(r.Context(), challengeHash); err != nil {
+		log.Printf("Couldn't delete login challenge: %s", err)
+	}
+}
+
+// checkSecondFactor accepts a TOTP code that hasn't been used before or an
+// unused recovery code, and uses it up. A wrong code counts as a failed
+// login, so codes can't be guessed faster than passwords.
+func (u *usersHandler) checkSecondFactor(w http.ResponseWriter, r *http.Request, user database.User, code string) bool {
+	totp, err := u.cfg.DbQueries.GetUserTOTP(r.Context(), user.ID)
+	if err != nil || !totp.ConfirmedAt.Valid {
+		utils.RespondWithError(w, http.StatusNotFound, "Two-factor authentication is not enabled", err)
+		return false
+	}
+
+	if len(code) != totpCodeLength {
+		used, err := u.cfg.DbQueries.UseRecoveryCode(r.Context(), database.UseRecoveryCodeParams{
+			UserID:   user.ID,
+			CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+			return false
+		}
+		if used == 0 {
+			u.recordLoginFailure(r, user.Email)
+			utils.RespondWithError(w, http.StatusUnauthorized, secondFactorInvalidCode, nil)
+			return false
+		}
+		log.Printf("User %s used a recovery code", user.ID)
+		return true
+	}
+
+	counter, ok := auth.ValidateTOTP(totp.Secret, code, u.cfg.Clock.Now())
+	if !ok {
+		u.recordLoginFailure(r, user.Email)
+		utils.RespondWithError(w, http.StatusUnauthorized, secondFactorInvalidCode, nil)
+		return false
+	}
+
+	used, err := u.cfg.DbQueries.UseTOTPCounter(r.Context(), database.UseTOTPCounterParams{
+		UserID:      user.ID,
+		LastCounter: counter,
+	})
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't check code", err)
+		return false
+	}
+	if used == 0 {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Code already used, wait for the next one", nil)
+		return false
+	}
+	return true
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userID uuid.UUID) ([]string, error) {
+	codes, err := auth.MakeRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		err := q.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashToken(code),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+func respondWithRecoveryCodes(w http.ResponseWriter, codes []string) {
+	utils.RespondWithJSON(w, http.StatusOK, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	})
+}
+
+// decodeCode reads a {"code": ...} body.
+func decodeCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return "", false
+	}
+	return params.Code, true
+}
@@ -1,15 +1,18 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/onkelwolle/chirpy/internal/auth"
 	"github.com/onkelwolle/chirpy/internal/config"
 	"github.com/onkelwolle/chirpy/internal/database"
-	"github.com/onkelwolle/chirpy/internal/models"
+	"github.com/onkelwolle/chirpy/internal/middleware"
 	"github.com/onkelwolle/chirpy/internal/utils"
 )
 
@@ -25,6 +28,7 @@ func (u *usersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -36,6 +40,18 @@ func (u *usersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	email, err := parseEmail(params.Email)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid email", err)
+		return
+	}
+
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid handle", err)
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
@@ -43,22 +59,24 @@ func (u *usersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := u.cfg.DbQueries.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
+		Handle:         handle,
 	})
-
+	if isUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "Email or handle already taken", err)
+		return
+	}
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, models.User{
-		Id:          user.ID.String(),
-		CreatedAt:   user.CreatedAt.String(),
-		UpdatedAt:   user.UpdatedAt.String(),
-		IsChirpyRed: user.IsChirpyRed,
-		Email:       user.Email,
-	})
+	if err := u.sendEmailVerification(r.Context(), user.ID, user.Email); err != nil {
+		log.Printf("Couldn't send verification email to new user %s: %s", user.ID, err)
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, convertUser(user))
 
 }
 
@@ -77,19 +95,52 @@ func (u *usersHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !u.checkLoginLock(w, r, params.Email) {
+		return
+	}
+
 	user, err := u.cfg.DbQueries.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
+		// Take as long as a wrong password would.
+		auth.DummyComparePassword(params.Password)
+		u.recordLoginFailure(r, params.Email)
 		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid email or password", err)
 		return
 	}
 
 	err = auth.ComparePassword(user.HashedPassword, params.Password)
 	if err != nil {
+		u.recordLoginFailure(r, params.Email)
 		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid email or password", err)
 		return
 	}
 
-	token, err := auth.MakeJWT(user.ID, string(u.cfg.Secret), time.Duration(u.cfg.AccessTokenExpiresIn)*time.Second)
+	totp, err := u.cfg.DbQueries.GetUserTOTP(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
+		return
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		u.startTwoFactorLogin(w, r, user)
+		return
+	}
+
+	u.clearLoginFailures(r, user.Email)
+	u.issueTokens(w, r, user)
+}
+
+// issueTokens starts a new session for user and responds with its access
+// and refresh tokens. Logging in restores an account waiting to be purged.
+func (u *usersHandler) issueTokens(w http.ResponseWriter, r *http.Request, user database.User) {
+	if user.DeletionRequestedAt.Valid {
+		if !u.cancelAccountDeletion(w, r, user.ID) {
+			return
+		}
+		user.DeletionRequestedAt = sql.NullTime{}
+	}
+
+	sessionID := uuid.New()
+	token, err := u.cfg.Keys.MakeJWT(user.ID, sessionID, time.Duration(u.cfg.AccessTokenExpiresIn)*time.Second)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create token", err)
 		return
@@ -103,24 +154,21 @@ func (u *usersHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	_, err = u.cfg.DbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
 		UserID:    user.ID,
-		Token:     refreshToken,
+		TokenHash: auth.HashToken(refreshToken),
 		ExpiresAt: time.Now().Add(time.Duration(u.cfg.RefreshTokenExpiresIn) * time.Second),
+		FamilyID:  sessionID,
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
 	})
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, models.User{
-		Id:           user.ID.String(),
-		CreatedAt:    user.CreatedAt.String(),
-		UpdatedAt:    user.UpdatedAt.String(),
-		Email:        user.Email,
-		IsChirpyRed:  user.IsChirpyRed,
-		Token:        token,
-		RefreshToken: refreshToken,
-	})
-
+	view := convertUser(user)
+	view.Token = token
+	view.RefreshToken = refreshToken
+	utils.RespondWithJSON(w, http.StatusOK, view)
 }
 
 func (u *usersHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -130,41 +178,104 @@ func (u *usersHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshTokenData, err := u.cfg.DbQueries.GetRefreshToken(r.Context(), refreshToken)
+	refreshTokenData, err := u.cfg.DbQueries.GetRefreshToken(r.Context(), auth.HashToken(refreshToken))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
 		return
 	}
 
+	// Refresh tokens are single use. Seeing a revoked one again means it
+	// was copied, so whoever holds the newest token of the family can no
+	// longer be trusted either.
+	if refreshTokenData.Revoked.Valid {
+		u.revokeFamily(r, refreshTokenData)
+		utils.RespondWithError(w, http.StatusUnauthorized, "Token revoked", nil)
+		return
+	}
+
 	if refreshTokenData.ExpiresAt.Before(time.Now()) {
 		utils.RespondWithError(w, http.StatusUnauthorized, "Token expired", nil)
 		return
 	}
 
-	if refreshTokenData.Revoked.Valid {
+	user, err := u.cfg.DbQueries.GetUserByID(r.Context(), refreshTokenData.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
+		return
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't refresh token", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	revoked, err := qtx.RevokeRefreshToken(r.Context(), refreshTokenData.TokenHash)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't refresh token", err)
+		return
+	}
+	if revoked == 0 {
+		// Another request rotated the token between our read and now.
+		tx.Rollback()
+		u.revokeFamily(r, refreshTokenData)
 		utils.RespondWithError(w, http.StatusUnauthorized, "Token revoked", nil)
 		return
 	}
 
-	user, err := u.cfg.DbQueries.GetUserByID(r.Context(), refreshTokenData.UserID)
+	_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(newRefreshToken),
+		ExpiresAt: time.Now().Add(time.Duration(u.cfg.RefreshTokenExpiresIn) * time.Second),
+		FamilyID:  refreshTokenData.FamilyID,
+		UserAgent: r.UserAgent(),
+		IpAddress: clientIP(r),
+	})
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
 		return
 	}
 
-	token, err := auth.MakeJWT(user.ID, string(u.cfg.Secret), time.Duration(u.cfg.AccessTokenExpiresIn)*time.Second)
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't refresh token", err)
+		return
+	}
+
+	token, err := u.cfg.Keys.MakeJWT(user.ID, refreshTokenData.FamilyID, time.Duration(u.cfg.AccessTokenExpiresIn)*time.Second)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't create token", err)
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}{
-		Token: token,
+		Token:        token,
+		RefreshToken: newRefreshToken,
 	})
 }
 
+// revokeFamily revokes every token descended from the same login after a
+// revoked refresh token was presented again.
+func (u *usersHandler) revokeFamily(r *http.Request, refreshTokenData database.RefreshToken) {
+	revoked, err := u.cfg.DbQueries.RevokeRefreshTokenFamily(r.Context(), refreshTokenData.FamilyID)
+	if err != nil {
+		log.Printf("Couldn't revoke refresh token family %s: %s", refreshTokenData.FamilyID, err)
+		return
+	}
+	log.Printf("SECURITY: reuse of revoked refresh token for user %s from %s, revoked %d active token(s) in family %s",
+		refreshTokenData.UserID, r.RemoteAddr, revoked, refreshTokenData.FamilyID)
+}
+
 func (u *usersHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -172,7 +283,7 @@ func (u *usersHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = u.cfg.DbQueries.RevokeRefreshToken(r.Context(), refreshToken)
+	_, err = u.cfg.DbQueries.RevokeRefreshToken(r.Context(), auth.HashToken(refreshToken))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't revoke token", err)
 		return
@@ -182,32 +293,43 @@ func (u *usersHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 }
 
 func (u *usersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	authToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
-		return
-	}
-
-	userID, err := auth.ValidateJWT(authToken, string(u.cfg.Secret))
-	if err != nil {
-		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
-		return
-	}
+	current := middleware.MustUser(r.Context())
 
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		log.Printf("Error decoding parameters: %s", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
 
+	email, err := parseEmail(params.Email)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid email", err)
+		return
+	}
+
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid handle", err)
+		return
+	}
+
+	pendingEmail, ok := u.checkEmailChange(w, r, current, email)
+	if !ok {
+		return
+	}
+	if pendingEmail != "" {
+		email = current.Email
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
@@ -215,21 +337,213 @@ func (u *usersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := u.cfg.DbQueries.UpdateUsersPasswordAndEmail(r.Context(), database.UpdateUsersPasswordAndEmailParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
-		ID:             userID,
+		Handle:         handle,
+		ID:             current.ID,
 	})
+	if isUniqueViolation(err) {
+		utils.RespondWithError(w, http.StatusConflict, "Email or handle already taken", err)
+		return
+	}
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, models.User{
-		Id:          user.ID.String(),
-		CreatedAt:   user.CreatedAt.String(),
-		UpdatedAt:   user.UpdatedAt.String(),
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
-	})
+	if pendingEmail != "" {
+		if err := u.sendEmailVerification(r.Context(), user.ID, pendingEmail); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+			return
+		}
+	}
+
+	view := convertUser(user)
+	view.PendingEmail = pendingEmail
+	utils.RespondWithJSON(w, http.StatusOK, view)
+
+}
+
+// PatchCurrentUser updates only the fields present in the request, including
+// the public profile. Changing the email or password needs the current
+// password, so a stolen access token isn't enough to take over the account.
+func (u *usersHandler) PatchCurrentUser(w http.ResponseWriter, r *http.Request) {
+	current := middleware.MustUser(r.Context())
+
+	type parameters struct {
+		Email           *string `json:"email"`
+		Password        *string `json:"password"`
+		Handle          *string `json:"handle"`
+		DisplayName     *string `json:"display_name"`
+		Bio             *string `json:"bio"`
+		Location        *string `json:"location"`
+		Website         *string `json:"website"`
+		AvatarURL       *string `json:"avatar_url"`
+		CurrentPassword string  `json:"current_password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Error decoding parameters: %s", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Email != nil || params.Password != nil {
+		if !u.confirmPassword(w, r, current, params.CurrentPassword) {
+			return
+		}
+	}
+
+	var email, pendingEmail string
+	if params.Email != nil {
+		email, err = parseEmail(*params.Email)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid email", err)
+			return
+		}
+		var ok bool
+		pendingEmail, ok = u.checkEmailChange(w, r, current, email)
+		if !ok {
+			return
+		}
+	}
+
+	var handle sql.NullString
+	if params.Handle != nil {
+		handle, err = parseHandle(*params.Handle)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid handle", err)
+			return
+		}
+	}
+
+	profile := database.UpdateUserProfileParams{ID: current.ID}
+	profileFields := []struct {
+		value *string
+		dest  *sql.NullString
+		parse func(string) (string, error)
+	}{
+		{params.DisplayName, &profile.DisplayName, func(v string) (string, error) {
+			return parseProfileText("display_name", v, maxDisplayNameLength, false)
+		}},
+		{params.Bio, &profile.Bio, func(v string) (string, error) {
+			return parseProfileText("bio", v, maxBioLength, true)
+		}},
+		{params.Location, &profile.Location, func(v string) (string, error) {
+			return parseProfileText("location", v, maxLocationLength, false)
+		}},
+		{params.Website, &profile.Website, func(v string) (string, error) {
+			return parseProfileURL("website", v)
+		}},
+		{params.AvatarURL, &profile.AvatarUrl, func(v string) (string, error) {
+			return parseProfileURL("avatar_url", v)
+		}},
+	}
+	profileChanged := false
+	for _, f := range profileFields {
+		if f.value == nil {
+			continue
+		}
+		value, err := f.parse(*f.value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		*f.dest = sql.NullString{String: value, Valid: true}
+		profileChanged = true
+	}
+
+	var hashedPassword string
+	if params.Password != nil {
+		hashedPassword, err = auth.HashPassword(*params.Password)
+		if errors.Is(err, auth.ErrEmptyPassword) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Password cannot be empty", err)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+			return
+		}
+	}
+
+	tx, err := u.cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := u.cfg.DbQueries.WithTx(tx)
+
+	if params.Email != nil && pendingEmail == "" && email != current.Email {
+		err = qtx.UpdateUserEmail(r.Context(), database.UpdateUserEmailParams{
+			Email: email,
+			ID:    current.ID,
+		})
+		if isUniqueViolation(err) {
+			utils.RespondWithError(w, http.StatusConflict, "Email or handle already taken", err)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+			return
+		}
+	}
+
+	if params.Handle != nil {
+		err = qtx.UpdateUserHandle(r.Context(), database.UpdateUserHandleParams{
+			Handle: handle,
+			ID:     current.ID,
+		})
+		if isUniqueViolation(err) {
+			utils.RespondWithError(w, http.StatusConflict, "Email or handle already taken", err)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+			return
+		}
+	}
+
+	if profileChanged {
+		if err := qtx.UpdateUserProfile(r.Context(), profile); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+			return
+		}
+	}
+
+	if params.Password != nil {
+		err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			HashedPassword: hashedPassword,
+			ID:             current.ID,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+			return
+		}
+	}
+
+	user, err := qtx.GetUserByID(r.Context(), current.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	if pendingEmail != "" {
+		if err := u.sendEmailVerification(r.Context(), user.ID, pendingEmail); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Couldn't send verification email", err)
+			return
+		}
+	}
 
+	view := convertUser(user)
+	view.PendingEmail = pendingEmail
+	utils.RespondWithJSON(w, http.StatusOK, view)
 }
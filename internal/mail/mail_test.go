@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := FileMailer{Dir: dir, From: "chirpy@example.com"}
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "walt@breakingbad.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one mail file, got %v (%v)", files, err)
+	}
+	if !strings.HasSuffix(files[0], "-walt@breakingbad.com.eml") {
+		t.Errorf("unexpected file name %s", files[0])
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"From: chirpy@example.com\r\n", "To: walt@breakingbad.com\r\n", "Subject: Hello\r\n", "\r\n\r\nline one\r\nline two"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected mail to contain %q, got %q", want, content)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	if got := sanitize("../../etc/passwd"); strings.Contains(got, "/") {
+		t.Errorf("expected no slashes, got %s", got)
+	}
+}
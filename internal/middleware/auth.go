@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	credentialKey
+)
+
+// credential is what the caller authenticated with.
+type credential struct {
+	sessionID uuid.NullUUID
+	// personal is set for personal access tokens, which only reach routes
+	// whose scope they were granted.
+	personal bool
+	scopes   []string
+}
+
+// RequireAuth rejects requests without a valid access token. The
+// authenticated user is loaded once and made available to the handler
+// through UserFromContext.
+//
+// scope is what a personal access token needs to use the route. Routes
+// without a scope only accept tokens from an interactive login.
+func RequireAuth(cfg *config.ApiConfig, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, err := authenticate(cfg, r)
+			if err != nil {
+				respondUnauthorized(w, r, err)
+				return
+			}
+			if !authorize(w, ctx, scope) {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// OptionalAuth lets anonymous requests through but still rejects a token
+// that is present and invalid, so a client with an expired token finds out
+// instead of silently getting the anonymous view.
+func OptionalAuth(cfg *config.ApiConfig, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, err := authenticate(cfg, r)
+			if err != nil {
+				respondUnauthorized(w, r, err)
+				return
+			}
+			if !authorize(w, ctx, scope) {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserFromContext returns the user authenticated by RequireAuth or
+// OptionalAuth.
+func UserFromContext(ctx context.Context) (database.User, bool) {
+	user, ok := ctx.Value(userKey).(database.User)
+	return user, ok
+}
+
+// MustUser returns the authenticated user and panics if the route was
+// registered without RequireAuth, so a missing check fails loudly instead
+// of running the handler as the zero user.
+func MustUser(ctx context.Context) database.User {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		panic("middleware: no authenticated user in context, is the route wrapped in RequireAuth?")
+	}
+	return user
+}
+
+// ViewerID returns the authenticated user's ID on optional-auth routes, or
+// an invalid NullUUID for anonymous requests.
+func ViewerID(ctx context.Context) uuid.NullUUID {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: user.ID, Valid: true}
+}
+
+// SessionID returns the login session the caller's access token was issued
+// for, if it carries one.
+func SessionID(ctx context.Context) uuid.NullUUID {
+	cred, _ := ctx.Value(credentialKey).(credential)
+	return cred.sessionID
+}
+
+// UsesPersonalAccessToken reports whether the caller authenticated with a
+// personal access token rather than a login.
+func UsesPersonalAccessToken(ctx context.Context) bool {
+	cred, _ := ctx.Value(credentialKey).(credential)
+	return cred.personal
+}
+
+// authenticate returns the request context with the caller's user and
+// credential added. The bearer token is either a JWT access token or a
+// personal access token.
+func authenticate(cfg *config.ApiConfig, r *http.Request) (context.Context, error) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var userID uuid.UUID
+	var cred credential
+	if auth.IsPersonalAccessToken(bearerToken) {
+		pat, err := cfg.DbQueries.GetPersonalAccessTokenByHash(r.Context(), auth.HashToken(bearerToken))
+		if err != nil {
+			return nil, fmt.Errorf("unknown personal access token: %w", err)
+		}
+		if err := cfg.DbQueries.TouchPersonalAccessToken(r.Context(), pat.ID); err != nil {
+			log.Printf("Couldn't update last use of personal access token %s: %s", pat.ID, err)
+		}
+		userID = pat.UserID
+		cred = credential{personal: true, scopes: pat.Scopes}
+	} else {
+		claims, err := cfg.Keys.ParseJWT(bearerToken)
+		if err != nil {
+			return nil, err
+		}
+		userID = claims.UserID
+		cred = credential{sessionID: claims.SessionID}
+	}
+
+	user, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load user %s: %w", userID, err)
+	}
+	// An account waiting to be purged only comes back through a login.
+	if user.DeletionRequestedAt.Valid {
+		return nil, fmt.Errorf("user %s is being deleted", userID)
+	}
+
+	ctx := context.WithValue(r.Context(), userKey, user)
+	ctx = context.WithValue(ctx, credentialKey, cred)
+	return ctx, nil
+}
+
+// authorize answers 403 with an insufficient_scope challenge when a personal
+// access token wasn't granted scope. Sessions are never limited by scope.
+func authorize(w http.ResponseWriter, ctx context.Context, scope string) bool {
+	cred, _ := ctx.Value(credentialKey).(credential)
+	if !cred.personal || auth.HasScope(cred.scopes, scope) {
+		return true
+	}
+
+	challenge := `Bearer realm="chirpy", error="insufficient_scope"`
+	if scope != "" {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	utils.RespondWithError(w, http.StatusForbidden, "Token is missing the required scope", nil)
+	return false
+}
+
+// respondUnauthorized follows RFC 6750: a request without credentials only
+// gets the challenge, one with bad credentials also gets invalid_token.
+func respondUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	challenge := `Bearer realm="chirpy"`
+	if r.Header.Get("Authorization") != "" {
+		challenge += `, error="invalid_token"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+)
+
+// These cases are all rejected before the user lookup, so they run without
+// a database.
+func TestRequireAuthRejects(t *testing.T) {
+	cfg := &config.ApiConfig{Keys: auth.NewKeySet([]byte("mysecret"), time.Hour)}
+
+	tests := []struct {
+		name      string
+		header    string
+		challenge string
+	}{
+		{name: "missing header", header: "", challenge: `Bearer realm="chirpy"`},
+		{name: "wrong scheme", header: "Basic abc", challenge: `Bearer realm="chirpy", error="invalid_token"`},
+		{name: "invalid token", header: "Bearer not-a-jwt", challenge: `Bearer realm="chirpy", error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := RequireAuth(cfg, auth.ScopeChirpsRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if called {
+				t.Fatalf("expected handler not to be called")
+			}
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("expected status 401, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected challenge %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+}
+
+func TestOptionalAuthAnonymous(t *testing.T) {
+	cfg := &config.ApiConfig{Keys: auth.NewKeySet([]byte("mysecret"), time.Hour)}
+
+	called := false
+	h := OptionalAuth(cfg, auth.ScopeChirpsRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if ViewerID(r.Context()).Valid {
+			t.Errorf("expected anonymous viewer")
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !called {
+		t.Fatalf("expected handler to be called")
+	}
+}
+
+func TestAuthorizeScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		cred     credential
+		scope    string
+		expected bool
+	}{
+		{name: "login token", cred: credential{}, scope: "", expected: true},
+		{name: "granted scope", cred: credential{personal: true, scopes: []string{auth.ScopeChirpsWrite}}, scope: auth.ScopeChirpsWrite, expected: true},
+		{name: "missing scope", cred: credential{personal: true, scopes: []string{auth.ScopeChirpsRead}}, scope: auth.ScopeChirpsWrite, expected: false},
+		{name: "login-only route", cred: credential{personal: true, scopes: []string{auth.ScopeChirpsRead}}, scope: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), credentialKey, tt.cred)
+			rec := httptest.NewRecorder()
+
+			if got := authorize(rec, ctx, tt.scope); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			if !tt.expected && rec.Code != http.StatusForbidden {
+				t.Errorf("expected status 403, got %d", rec.Code)
+			}
+		})
+	}
+}
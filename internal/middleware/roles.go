@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/onkelwolle/chirpy/internal/auth"
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// RequireRole only lets through callers whose role includes role. It runs
+// RequireAuth first, without a scope, so personal access tokens never reach
+// these routes. Since the user is loaded on every request, granting or
+// revoking a role takes effect immediately rather than when the caller's
+// token expires.
+func RequireRole(cfg *config.ApiConfig, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return RequireAuth(cfg, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := MustUser(r.Context())
+			if !auth.HasRole(user.Role, role) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy", error="insufficient_scope"`)
+				utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
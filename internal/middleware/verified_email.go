@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/utils"
+)
+
+// RequireVerifiedEmail runs RequireAuth and, when the config asks for it,
+// turns away users who haven't confirmed their email yet.
+func RequireVerifiedEmail(cfg *config.ApiConfig, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return RequireAuth(cfg, scope)(requireVerifiedEmail(cfg, next))
+	}
+}
+
+func requireVerifiedEmail(cfg *config.ApiConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.RequireVerifiedEmail && !MustUser(r.Context()).EmailVerifiedAt.Valid {
+			utils.RespondWithError(w, http.StatusForbidden, "Verify your email first", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
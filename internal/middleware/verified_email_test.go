@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onkelwolle/chirpy/internal/config"
+	"github.com/onkelwolle/chirpy/internal/database"
+)
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	verified := sql.NullTime{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name     string
+		required bool
+		verified sql.NullTime
+		expected int
+	}{
+		{name: "not required", required: false, expected: http.StatusOK},
+		{name: "verified", required: true, verified: verified, expected: http.StatusOK},
+		{name: "unverified", required: true, expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ApiConfig{RequireVerifiedEmail: tt.required}
+			h := requireVerifiedEmail(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			user := database.User{EmailVerifiedAt: tt.verified}
+			req = req.WithContext(context.WithValue(req.Context(), userKey, user))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
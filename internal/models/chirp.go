@@ -6,4 +6,60 @@ type Chirp struct {
 	UpdatedAt string `json:"updated_at"`
 	Body      string `json:"body"`
 	UserID    string `json:"user_id"`
+	ParentID  string `json:"parent_id,omitempty"`
+	RootID    string `json:"root_id,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Hidden    bool   `json:"hidden,omitempty"`
+	LikeCount int    `json:"like_count"`
+	LikedByMe bool   `json:"liked_by_me"`
+	RechirpOf *Chirp `json:"rechirp_of,omitempty"`
+	QuoteOf   *Chirp `json:"quote_of,omitempty"`
+}
+
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type ChirpSearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type ChirpRevision struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	ChirpID   string `json:"chirp_id"`
+	Body      string `json:"body"`
+}
+
+type ThreadChirp struct {
+	Chirp
+	Depth int `json:"depth"`
+}
+
+type TrendingHashtag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int    `json:"chirp_count"`
+}
+
+type Report struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	ChirpID   string `json:"chirp_id"`
+	Reason    string `json:"reason"`
+	Details   string `json:"details,omitempty"`
+	Status    string `json:"status"`
+}
+
+type ReportedChirp struct {
+	ChirpID         string   `json:"chirp_id"`
+	Body            string   `json:"body"`
+	UserID          string   `json:"user_id"`
+	Hidden          bool     `json:"hidden"`
+	ReportCount     int      `json:"report_count"`
+	Reasons         []string `json:"reasons"`
+	Flags           []string `json:"flags"`
+	FirstReportedAt string   `json:"first_reported_at"`
 }
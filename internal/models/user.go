@@ -1,11 +1,37 @@
 package models
 
 type User struct {
-	Id           string `json:"id"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	Email        string `json:"email"`
-	IsChirpyRed  bool   `json:"is_chirpy_red"`
+	Id        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Email     string `json:"email"`
+	// EmailVerified tells whether Email has been confirmed. PendingEmail is
+	// an address the user changed to that hasn't been confirmed yet.
+	EmailVerified bool   `json:"email_verified"`
+	PendingEmail  string `json:"pending_email,omitempty"`
+	IsChirpyRed   bool   `json:"is_chirpy_red"`
+	Handle        string `json:"handle,omitempty"`
+	Profile
+	Role         string `json:"role,omitempty"`
 	Token        string `json:"token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
+
+// PublicUser is the view of a user that other users are allowed to see.
+type PublicUser struct {
+	Id          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	IsChirpyRed bool   `json:"is_chirpy_red"`
+	Handle      string `json:"handle,omitempty"`
+	Profile
+}
+
+// Profile is what a user tells others about themselves. Empty fields are
+// not set.
+type Profile struct {
+	DisplayName string `json:"display_name,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Website     string `json:"website,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
@@ -0,0 +1,254 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const mask = "****"
+
+// WordList matches banned words case-insensitively on word boundaries. The
+// list can be changed while the server is running; the pattern is only
+// recompiled when it does.
+type WordList struct {
+	action Action
+
+	mu      sync.RWMutex
+	words   map[string]bool
+	pattern *regexp.Regexp
+}
+
+func NewWordList(action Action, words []string) *WordList {
+	wl := &WordList{action: action}
+	wl.SetWords(words)
+	return wl
+}
+
+func (wl *WordList) Name() string {
+	return "word_list"
+}
+
+func (wl *WordList) Check(body string) Verdict {
+	wl.mu.RLock()
+	pattern := wl.pattern
+	wl.mu.RUnlock()
+
+	if pattern == nil || !pattern.MatchString(body) {
+		return Verdict{Action: ActionAllow, Body: body}
+	}
+
+	v := Verdict{Action: wl.action, Body: body, Reason: "contains a banned word"}
+	if wl.action == ActionMask {
+		v.Body = pattern.ReplaceAllString(body, mask)
+	}
+	return v
+}
+
+// Words returns the banned words in alphabetical order.
+func (wl *WordList) Words() []string {
+	wl.mu.RLock()
+	defer wl.mu.RUnlock()
+
+	words := make([]string, 0, len(wl.words))
+	for w := range wl.words {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func (wl *WordList) SetWords(words []string) {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		if w = normalizeWord(w); w != "" {
+			set[w] = true
+		}
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.words = set
+	wl.compile()
+}
+
+func (wl *WordList) Add(word string) {
+	word = normalizeWord(word)
+	if word == "" {
+		return
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.words[word] = true
+	wl.compile()
+}
+
+func (wl *WordList) Remove(word string) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	delete(wl.words, normalizeWord(word))
+	wl.compile()
+}
+
+// compile rebuilds the pattern. The caller must hold mu for writing.
+func (wl *WordList) compile() {
+	if len(wl.words) == 0 {
+		wl.pattern = nil
+		return
+	}
+
+	quoted := make([]string, 0, len(wl.words))
+	for w := range wl.words {
+		quoted = append(quoted, regexp.QuoteMeta(w))
+	}
+	sort.Strings(quoted)
+	wl.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimSpace(w))
+}
+
+// LoadWordsFile reads one word per line. Blank lines and lines starting
+// with # are skipped.
+func LoadWordsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read word list %s: %w", path, err)
+	}
+	return words, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://)?(?:[a-z0-9-]+\.)+[a-z]{2,}(?:/\S*)?`)
+
+// LinkBlocklist matches links to blocked domains and their subdomains.
+type LinkBlocklist struct {
+	action  Action
+	domains map[string]bool
+}
+
+func NewLinkBlocklist(action Action, domains []string) *LinkBlocklist {
+	set := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		if d = normalizeWord(d); d != "" {
+			set[d] = true
+		}
+	}
+	return &LinkBlocklist{action: action, domains: set}
+}
+
+func (lb *LinkBlocklist) Name() string {
+	return "link_blocklist"
+}
+
+func (lb *LinkBlocklist) Check(body string) Verdict {
+	blocked := false
+	masked := linkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		if !lb.isBlocked(link) {
+			return link
+		}
+		blocked = true
+		return mask
+	})
+	if !blocked {
+		return Verdict{Action: ActionAllow, Body: body}
+	}
+
+	v := Verdict{Action: lb.action, Body: body, Reason: "links to a blocked domain"}
+	if lb.action == ActionMask {
+		v.Body = masked
+	}
+	return v
+}
+
+func (lb *LinkBlocklist) isBlocked(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for host != "" {
+		if lb.domains[host] {
+			return true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			break
+		}
+		host = parent
+	}
+	return false
+}
+
+// RepeatedChars matches bodies that repeat the same character more than max
+// times in a row, e.g. "noooooooooooo".
+type RepeatedChars struct {
+	action Action
+	max    int
+}
+
+func NewRepeatedChars(action Action, max int) *RepeatedChars {
+	return &RepeatedChars{action: action, max: max}
+}
+
+func (rc *RepeatedChars) Name() string {
+	return "repeated_chars"
+}
+
+func (rc *RepeatedChars) Check(body string) Verdict {
+	var out strings.Builder
+	found := false
+	var prev rune
+	run := 0
+	for _, r := range body {
+		if r == prev {
+			run++
+		} else {
+			prev = r
+			run = 1
+		}
+		if run > rc.max {
+			found = true
+			continue
+		}
+		out.WriteRune(r)
+	}
+	if !found {
+		return Verdict{Action: ActionAllow, Body: body}
+	}
+
+	v := Verdict{
+		Action: rc.action,
+		Body:   body,
+		Reason: fmt.Sprintf("repeats a character more than %d times", rc.max),
+	}
+	// Masking a run trims it down to the allowed length.
+	if rc.action == ActionMask {
+		v.Body = out.String()
+	}
+	return v
+}
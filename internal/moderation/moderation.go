@@ -0,0 +1,103 @@
+package moderation
+
+import (
+	"strings"
+)
+
+// Action is what a filter wants done with a chirp that matched it.
+type Action int
+
+const (
+	// ActionAllow lets the chirp through unchanged.
+	ActionAllow Action = iota
+	// ActionMask replaces the offending text and lets the chirp through.
+	ActionMask
+	// ActionFlag lets the chirp through but queues it for review.
+	ActionFlag
+	// ActionReject refuses the chirp.
+	ActionReject
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionMask:
+		return "mask"
+	case ActionFlag:
+		return "flag"
+	case ActionReject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// ParseAction parses the names returned by Action.String.
+func ParseAction(s string) (Action, bool) {
+	switch strings.ToLower(s) {
+	case "allow":
+		return ActionAllow, true
+	case "mask":
+		return ActionMask, true
+	case "flag":
+		return ActionFlag, true
+	case "reject":
+		return ActionReject, true
+	}
+	return ActionAllow, false
+}
+
+// Verdict is the outcome of running a single filter.
+type Verdict struct {
+	Action Action
+	// Body is the body to continue with. Filters that mask return the
+	// masked body, all others return their input.
+	Body   string
+	Reason string
+}
+
+// Filter inspects a chirp body.
+type Filter interface {
+	Name() string
+	Check(body string) Verdict
+}
+
+// Result is the outcome of running a Chain.
+type Result struct {
+	Body     string
+	Rejected bool
+	// Reasons holds why the chirp was rejected, or why it was flagged if it
+	// was not rejected.
+	Reasons []string
+	Flagged bool
+}
+
+// Chain runs filters in order, passing each the body left by the previous
+// one. A rejection stops the chain.
+type Chain struct {
+	filters []Filter
+}
+
+func NewChain(filters ...Filter) *Chain {
+	return &Chain{filters: filters}
+}
+
+func (c *Chain) Check(body string) Result {
+	result := Result{Body: body}
+	for _, f := range c.filters {
+		v := f.Check(result.Body)
+		switch v.Action {
+		case ActionReject:
+			return Result{
+				Body:     body,
+				Rejected: true,
+				Reasons:  []string{v.Reason},
+			}
+		case ActionMask:
+			result.Body = v.Body
+		case ActionFlag:
+			result.Flagged = true
+			result.Reasons = append(result.Reasons, v.Reason)
+		}
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package moderation
+
+import (
+	"testing"
+)
+
+func TestWordListMask(t *testing.T) {
+	wl := NewWordList(ActionMask, []string{"kerfuffle", "sharbert", "fornax"})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "no banned words",
+			body:     "I had something interesting for breakfast",
+			expected: "I had something interesting for breakfast",
+		},
+		{
+			name:     "banned word in any case",
+			body:     "This is a kerfuffle opinion I need to share with the world, Sharbert",
+			expected: "This is a **** opinion I need to share with the world, ****",
+		},
+		{
+			name:     "banned word with punctuation attached",
+			body:     "I really need a kerfuffle! to go to bed sooner, Fornax !",
+			expected: "I really need a ****! to go to bed sooner, **** !",
+		},
+		{
+			name:     "banned word inside another word",
+			body:     "sharbertful",
+			expected: "sharbertful",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := wl.Check(tt.body)
+			if v.Body != tt.expected {
+				t.Errorf("expected body %q, got %q", tt.expected, v.Body)
+			}
+		})
+	}
+}
+
+func TestWordListUpdate(t *testing.T) {
+	wl := NewWordList(ActionReject, nil)
+	if v := wl.Check("hello world"); v.Action != ActionAllow {
+		t.Fatalf("expected allow for empty list, got %v", v.Action)
+	}
+
+	wl.Add("World")
+	if v := wl.Check("hello world"); v.Action != ActionReject {
+		t.Fatalf("expected reject after adding word, got %v", v.Action)
+	}
+
+	wl.Remove("world")
+	if v := wl.Check("hello world"); v.Action != ActionAllow {
+		t.Fatalf("expected allow after removing word, got %v", v.Action)
+	}
+}
+
+func TestLinkBlocklist(t *testing.T) {
+	lb := NewLinkBlocklist(ActionReject, []string{"spam.example"})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected Action
+	}{
+		{name: "no link", body: "just words", expected: ActionAllow},
+		{name: "allowed link", body: "see https://boot.dev/courses", expected: ActionAllow},
+		{name: "blocked link", body: "buy at https://spam.example/now", expected: ActionReject},
+		{name: "blocked subdomain without scheme", body: "go to shop.spam.example", expected: ActionReject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := lb.Check(tt.body)
+			if v.Action != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, v.Action)
+			}
+		})
+	}
+}
+
+func TestRepeatedChars(t *testing.T) {
+	rc := NewRepeatedChars(ActionMask, 3)
+
+	v := rc.Check("nooooo way")
+	if v.Action != ActionMask {
+		t.Fatalf("expected mask, got %v", v.Action)
+	}
+	if v.Body != "nooo way" {
+		t.Errorf("expected body %q, got %q", "nooo way", v.Body)
+	}
+
+	if v := rc.Check("nooo way"); v.Action != ActionAllow {
+		t.Errorf("expected allow, got %v", v.Action)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := NewChain(
+		NewWordList(ActionMask, []string{"kerfuffle"}),
+		NewRepeatedChars(ActionFlag, 3),
+		NewLinkBlocklist(ActionReject, []string{"spam.example"}),
+	)
+
+	result := chain.Check("what a kerfuffle!!!!")
+	if result.Rejected {
+		t.Fatalf("expected chirp to pass")
+	}
+	if !result.Flagged {
+		t.Errorf("expected chirp to be flagged")
+	}
+	if result.Body != "what a ****!!!!" {
+		t.Errorf("expected masked body, got %q", result.Body)
+	}
+
+	result = chain.Check("kerfuffle at spam.example")
+	if !result.Rejected {
+		t.Fatalf("expected chirp to be rejected")
+	}
+	if result.Body != "kerfuffle at spam.example" {
+		t.Errorf("expected rejected result to keep the original body, got %q", result.Body)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onkelwolle/chirpy/internal/auth"
+)
+
+// loadSigningKeys builds the key set access tokens are signed with. Without
+// JWT_KEYS_DIR tokens are signed with HS256 and SECRET, as before. With it,
+// every <kid>.pem in the directory is loaded; the newest key signs and the
+// older ones verify until the tokens they signed have expired. An empty
+// directory gets a freshly generated Ed25519 key.
+func loadSigningKeys(secret []byte, accessTokenExpiresIn time.Duration) *auth.KeySet {
+	keys := auth.NewKeySet(secret, accessTokenExpiresIn)
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return keys
+	}
+
+	loaded, err := readSigningKeys(dir)
+	if err != nil {
+		log.Fatalf("Cannot load signing keys: %s", err)
+	}
+	if len(loaded) == 0 {
+		key, err := writeNewSigningKey(dir)
+		if err != nil {
+			log.Fatalf("Cannot create signing key: %s", err)
+		}
+		loaded = append(loaded, key)
+	}
+
+	// A key retired when its successor was created.
+	now := time.Now()
+	for i, key := range loaded[:len(loaded)-1] {
+		retiresAt := loaded[i+1].CreatedAt.Add(accessTokenExpiresIn)
+		if now.After(retiresAt) {
+			removeSigningKey(dir, key.ID)
+			continue
+		}
+		keys.AddRetired(key, retiresAt)
+	}
+	keys.Rotate(loaded[len(loaded)-1], now)
+
+	if v := os.Getenv("JWT_KEY_ROTATION"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid JWT_KEY_ROTATION %q", v)
+		}
+		go rotateSigningKeys(keys, dir, interval)
+	}
+
+	return keys
+}
+
+// rotateSigningKeys replaces the signing key once it is older than
+// interval and deletes retired keys once they can't verify anything.
+func rotateSigningKeys(keys *auth.KeySet, dir string, interval time.Duration) {
+	check := time.Hour
+	if interval < check {
+		check = interval
+	}
+
+	for {
+		current, _ := keys.Current()
+		if time.Since(current.CreatedAt) >= interval {
+			key, err := writeNewSigningKey(dir)
+			if err != nil {
+				log.Printf("Cannot rotate signing key: %s", err)
+			} else {
+				keys.Rotate(key, time.Now())
+				log.Printf("Rotated signing key %s to %s", current.ID, key.ID)
+			}
+		}
+
+		for _, id := range keys.Prune(time.Now()) {
+			removeSigningKey(dir, id)
+		}
+
+		time.Sleep(check)
+	}
+}
+
+// readSigningKeys returns the keys in dir, oldest first.
+func readSigningKeys(dir string) ([]auth.SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var keys []auth.SigningKey
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := auth.ParseSigningKeyPEM(id, data, info.ModTime())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func writeNewSigningKey(dir string) (auth.SigningKey, error) {
+	key, err := auth.GenerateSigningKey(time.Now())
+	if err != nil {
+		return auth.SigningKey{}, err
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return auth.SigningKey{}, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return auth.SigningKey{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, key.ID+".pem"), data, 0o600); err != nil {
+		return auth.SigningKey{}, err
+	}
+	return key, nil
+}
+
+func removeSigningKey(dir, id string) {
+	if err := os.Remove(filepath.Join(dir, id+".pem")); err != nil {
+		log.Printf("Cannot remove retired signing key %s: %s", id, err)
+		return
+	}
+	log.Printf("Removed retired signing key %s", id)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/onkelwolle/chirpy/internal/auth"
+)
+
+// loadLockoutPolicies returns how failed logins are throttled per account
+// and per IP address. An IP address gets a higher threshold than an
+// account, since several users can share one.
+func loadLockoutPolicies() (auth.LockoutPolicy, auth.LockoutPolicy) {
+	duration := envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+
+	account := auth.LockoutPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		Threshold:       envInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration: duration,
+	}
+	ip := auth.LockoutPolicy{
+		FreeAttempts:    20,
+		BaseDelay:       time.Second,
+		Threshold:       envInt("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+		LockoutDuration: duration,
+	}
+	return account, ip
+}
+
+func envInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		log.Printf("Invalid %s %q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/onkelwolle/chirpy/internal/mail"
+)
+
+// loadMailer picks how email is delivered. SMTP_ADDR sends through an SMTP
+// server, MAIL_DIR writes every message to a file in that directory, and
+// without either messages are only logged.
+func loadMailer() mail.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Chirpy <no-reply@localhost>"
+	}
+
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		return mail.SMTPMailer{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+	if dir := os.Getenv("MAIL_DIR"); dir != "" {
+		return mail.FileMailer{Dir: dir, From: from}
+	}
+
+	log.Println("Neither SMTP_ADDR nor MAIL_DIR is set, emails will only be logged")
+	return mail.LogMailer{}
+}
+
+// publicURL is where the server is reachable from outside, for links in
+// emails.
+func publicURL() string {
+	if v := os.Getenv("PUBLIC_URL"); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	return "http://localhost:8080"
+}
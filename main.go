@@ -6,12 +6,15 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/onkelwolle/chirpy/internal/auth"
 	"github.com/onkelwolle/chirpy/internal/config"
 	"github.com/onkelwolle/chirpy/internal/database"
 	"github.com/onkelwolle/chirpy/internal/handler"
+	"github.com/onkelwolle/chirpy/internal/middleware"
 )
 
 func main() {
@@ -25,14 +28,29 @@ func main() {
 
 	mux := http.NewServeMux()
 
+	dbQueries := database.New(db)
+	moderationChain, bannedWords := loadModeration(db, dbQueries)
+
 	apiCfg := &config.ApiConfig{
-		Templates:             loadTemplates(),
-		DbQueries:             database.New(db),
-		Secret:                []byte(os.Getenv("SECRET")),
-		PolkaWebhookSecret:    []byte(os.Getenv("POLKA_KEY")),
-		AccessTokenExpiresIn:  60 * 60 * 1,       // 1 hour
-		RefreshTokenExpiresIn: 60 * 60 * 24 * 60, // 60 days
+		Templates:                  loadTemplates(),
+		DB:                         db,
+		DbQueries:                  dbQueries,
+		Secret:                     []byte(os.Getenv("SECRET")),
+		PolkaWebhookSecret:         []byte(os.Getenv("POLKA_KEY")),
+		AccessTokenExpiresIn:       60 * 60 * 1,       // 1 hour
+		RefreshTokenExpiresIn:      60 * 60 * 24 * 60, // 60 days
+		Moderation:                 moderationChain,
+		BannedWords:                bannedWords,
+		Clock:                      auth.SystemClock{},
+		Mailer:                     loadMailer(),
+		PublicURL:                  publicURL(),
+		RequireVerifiedEmail:       os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+		AccountDeletionGracePeriod: envDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
 	}
+	apiCfg.AccountLockout, apiCfg.IPLockout = loadLockoutPolicies()
+	apiCfg.Keys = loadSigningKeys(apiCfg.Secret, time.Duration(apiCfg.AccessTokenExpiresIn)*time.Second)
+
+	go purgeDeletedAccounts(db, dbQueries, apiCfg.AccountDeletionGracePeriod)
 
 	fileServer := http.FileServer(http.Dir("."))
 	configureEndpoints(mux, apiCfg, fileServer)
@@ -55,30 +73,103 @@ func configureEndpoints(mux *http.ServeMux, apiCfg *config.ApiConfig, fileServer
 	metricsHandler := handler.NewMetricsHandler(apiCfg)
 	userHandler := handler.NewUsersHandler(apiCfg)
 	webhookHandler := handler.NewWebhooksHandler(apiCfg)
+	followsHandler := handler.NewFollowsHandler(apiCfg)
+	hashtagsHandler := handler.NewHashtagsHandler(apiCfg)
+	moderationHandler := handler.NewModerationHandler(apiCfg)
+	rolesHandler := handler.NewRolesHandler(apiCfg)
+	sessionsHandler := handler.NewSessionsHandler(apiCfg)
+	jwksHandler := handler.NewJWKSHandler(apiCfg)
+	tokensHandler := handler.NewTokensHandler(apiCfg)
+
+	requireAuth := func(scope string, h http.HandlerFunc) http.Handler {
+		return middleware.RequireAuth(apiCfg, scope)(h)
+	}
+	requireVerifiedEmail := func(scope string, h http.HandlerFunc) http.Handler {
+		return middleware.RequireVerifiedEmail(apiCfg, scope)(h)
+	}
+	optionalAuth := func(scope string, h http.HandlerFunc) http.Handler {
+		return middleware.OptionalAuth(apiCfg, scope)(h)
+	}
+	requireAdmin := middleware.RequireRole(apiCfg, auth.RoleAdmin)
+	requireModerator := middleware.RequireRole(apiCfg, auth.RoleModerator)
 
 	mux.Handle("/app/", metricsHandler.MiddlewareMetricsInc(http.StripPrefix("/app/", fileServer)))
 
-	mux.HandleFunc("GET /admin/metrics", metricsHandler.MetricsHandler)
-	mux.HandleFunc("POST /admin/reset", metricsHandler.ResetMetricsHandler)
-
-	mux.HandleFunc("POST /api/chirps", chirpHandler.CreateChirps)
-	mux.HandleFunc("GET /api/chirps", chirpHandler.GetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpId}", chirpHandler.GetChirpByID)
-	mux.HandleFunc("DELETE /api/chirps/{chirpId}", chirpHandler.DeleteChirp)
+	mux.Handle("GET /admin/metrics", requireAdmin(http.HandlerFunc(metricsHandler.MetricsHandler)))
+	mux.Handle("POST /admin/reset", requireAdmin(http.HandlerFunc(metricsHandler.ResetMetricsHandler)))
+	mux.Handle("PUT /admin/users/{id}/role", requireAdmin(http.HandlerFunc(rolesHandler.GrantRole)))
+	mux.Handle("DELETE /admin/users/{id}/role", requireAdmin(http.HandlerFunc(rolesHandler.RevokeRole)))
+	mux.Handle("DELETE /admin/users/{id}/lockout", requireAdmin(http.HandlerFunc(userHandler.UnlockUser)))
+	mux.Handle("GET /admin/moderation/words", requireModerator(http.HandlerFunc(moderationHandler.GetBannedWords)))
+	mux.Handle("POST /admin/moderation/words", requireModerator(http.HandlerFunc(moderationHandler.AddBannedWord)))
+	mux.Handle("DELETE /admin/moderation/words/{word}", requireModerator(http.HandlerFunc(moderationHandler.DeleteBannedWord)))
+	mux.Handle("GET /admin/moderation/reports", requireModerator(http.HandlerFunc(moderationHandler.GetReports)))
+	mux.Handle("POST /admin/moderation/chirps/{chirpId}/dismiss", requireModerator(http.HandlerFunc(moderationHandler.DismissReports)))
+	mux.Handle("POST /admin/moderation/chirps/{chirpId}/hide", requireModerator(http.HandlerFunc(moderationHandler.HideReportedChirp)))
+	mux.Handle("POST /admin/moderation/chirps/{chirpId}/delete", requireModerator(http.HandlerFunc(moderationHandler.DeleteReportedChirp)))
+
+	mux.Handle("POST /api/chirps", requireVerifiedEmail(auth.ScopeChirpsWrite, chirpHandler.CreateChirps))
+	mux.Handle("GET /api/chirps", optionalAuth(auth.ScopeChirpsRead, chirpHandler.GetChirps))
+	mux.Handle("GET /api/chirps/search", optionalAuth(auth.ScopeChirpsRead, chirpHandler.SearchChirps))
+	mux.Handle("GET /api/chirps/{chirpId}", optionalAuth(auth.ScopeChirpsRead, chirpHandler.GetChirpByID))
+	mux.Handle("PUT /api/chirps/{chirpId}", requireAuth(auth.ScopeChirpsWrite, chirpHandler.UpdateChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}", requireAuth(auth.ScopeChirpsWrite, chirpHandler.DeleteChirp))
+	mux.Handle("GET /api/chirps/{chirpId}/revisions", optionalAuth(auth.ScopeChirpsRead, chirpHandler.GetChirpRevisions))
+	mux.Handle("GET /api/chirps/{chirpId}/thread", optionalAuth(auth.ScopeChirpsRead, chirpHandler.GetChirpThread))
+	mux.Handle("POST /api/chirps/{chirpId}/like", requireAuth(auth.ScopeChirpsWrite, chirpHandler.LikeChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}/like", requireAuth(auth.ScopeChirpsWrite, chirpHandler.UnlikeChirp))
+	mux.Handle("POST /api/chirps/{chirpId}/reports", requireAuth(auth.ScopeChirpsWrite, chirpHandler.ReportChirp))
+
+	mux.HandleFunc("GET /api/hashtags/trending", hashtagsHandler.GetTrendingHashtags)
+	mux.Handle("GET /api/hashtags/{tag}/chirps", optionalAuth(auth.ScopeChirpsRead, hashtagsHandler.GetHashtagChirps))
 
 	mux.HandleFunc("POST /api/users", userHandler.CreateUser)
 	mux.HandleFunc("POST /api/login", userHandler.Login)
-	mux.HandleFunc("PUT /api/users", userHandler.UpdateUser)
+	mux.HandleFunc("POST /api/login/2fa", userHandler.LoginTwoFactor)
+	mux.HandleFunc("POST /api/password-reset", userHandler.RequestPasswordReset)
+	mux.HandleFunc("POST /api/password-reset/confirm", userHandler.ConfirmPasswordReset)
+	mux.HandleFunc("GET /reset-password", userHandler.ResetPasswordPage)
+	mux.HandleFunc("POST /api/users/verify-email", userHandler.VerifyEmail)
+	mux.HandleFunc("GET /verify-email", userHandler.VerifyEmailPage)
+	mux.Handle("POST /api/users/me/verify-email", requireAuth("", userHandler.ResendEmailVerification))
+	mux.Handle("PUT /api/users", requireAuth("", userHandler.UpdateUser))
+	mux.Handle("PATCH /api/users/me", requireAuth(auth.ScopeProfileWrite, userHandler.PatchCurrentUser))
+	mux.Handle("DELETE /api/users/me", requireAuth("", userHandler.DeleteCurrentUser))
+	mux.Handle("POST /api/users/me/2fa", requireAuth("", userHandler.EnrollTwoFactor))
+	mux.Handle("POST /api/users/me/2fa/confirm", requireAuth("", userHandler.ConfirmTwoFactor))
+	mux.Handle("DELETE /api/users/me/2fa", requireAuth("", userHandler.DisableTwoFactor))
+	mux.Handle("POST /api/users/me/2fa/recovery-codes", requireAuth("", userHandler.RegenerateRecoveryCodes))
+	mux.Handle("GET /api/users/me/mentions", requireAuth(auth.ScopeChirpsRead, userHandler.GetMyMentions))
 	mux.HandleFunc("POST /api/refresh", userHandler.RefreshToken)
 	mux.HandleFunc("POST /api/revoke", userHandler.RevokeToken)
 
+	mux.Handle("GET /api/sessions", requireAuth("", sessionsHandler.GetSessions))
+	mux.Handle("DELETE /api/sessions/{id}", requireAuth("", sessionsHandler.RevokeSession))
+	mux.Handle("POST /api/sessions/revoke-all", requireAuth("", sessionsHandler.RevokeAllSessions))
+
+	mux.Handle("POST /api/tokens", requireAuth("", tokensHandler.CreateToken))
+	mux.Handle("GET /api/tokens", requireAuth("", tokensHandler.GetTokens))
+	mux.Handle("DELETE /api/tokens/{id}", requireAuth("", tokensHandler.RevokeToken))
+
+	mux.HandleFunc("GET /api/users/{id}", userHandler.GetUser)
+	mux.Handle("POST /api/users/{id}/follow", requireAuth(auth.ScopeFollowsWrite, followsHandler.Follow))
+	mux.Handle("DELETE /api/users/{id}/follow", requireAuth(auth.ScopeFollowsWrite, followsHandler.Unfollow))
+	mux.HandleFunc("GET /api/users/{id}/followers", followsHandler.GetFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", followsHandler.GetFollowing)
+	mux.Handle("GET /api/timeline", requireAuth(auth.ScopeChirpsRead, followsHandler.GetTimeline))
+
 	mux.HandleFunc("POST /api/polka/webhooks", webhookHandler.PolkaWebhook)
 
 	mux.HandleFunc("GET /api/healthz", healthz)
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler.GetJWKS)
 }
 
 func loadTemplates() *template.Template {
-	tmpl, err := template.ParseFiles("templates/admin_metrics.html")
+	tmpl, err := template.ParseFiles(
+		"templates/admin_metrics.html",
+		"templates/reset_password.html",
+		"templates/verify_email.html",
+	)
 	if err != nil {
 		log.Println("Error loading templates:", err)
 	}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/onkelwolle/chirpy/internal/database"
+	"github.com/onkelwolle/chirpy/internal/moderation"
+)
+
+var defaultBannedWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+// loadModeration builds the filter chain chirps are checked against. Banned
+// words come from the banned_words table, which BANNED_WORDS_FILE seeds the
+// first time it is set; each filter's action can be overridden from the
+// environment.
+func loadModeration(db *sql.DB, dbQueries *database.Queries) (*moderation.Chain, *moderation.WordList) {
+	if path := os.Getenv("BANNED_WORDS_FILE"); path != "" {
+		if err := importBannedWords(db, dbQueries, path); err != nil {
+			log.Printf("Cannot import banned words file: %s", err)
+		}
+	}
+
+	words, err := dbQueries.GetBannedWords(context.Background())
+	if err != nil {
+		log.Printf("Cannot load banned words, using defaults: %s", err)
+		words = defaultBannedWords
+	}
+
+	var blockedDomains []string
+	if domains := os.Getenv("BLOCKED_LINK_DOMAINS"); domains != "" {
+		blockedDomains = strings.Split(domains, ",")
+	}
+
+	maxRepeated := 10
+	if v := os.Getenv("MAX_REPEATED_CHARS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Printf("Invalid MAX_REPEATED_CHARS %q, using %d", v, maxRepeated)
+		} else {
+			maxRepeated = n
+		}
+	}
+
+	bannedWords := moderation.NewWordList(envAction("BANNED_WORDS_ACTION", moderation.ActionMask), words)
+	chain := moderation.NewChain(
+		bannedWords,
+		moderation.NewLinkBlocklist(envAction("BLOCKED_LINKS_ACTION", moderation.ActionReject), blockedDomains),
+		moderation.NewRepeatedChars(envAction("REPEATED_CHARS_ACTION", moderation.ActionFlag), maxRepeated),
+	)
+	return chain, bannedWords
+}
+
+// importBannedWords adds the words in a file to the banned_words table,
+// unless that file was imported before. After the first import the admin
+// endpoints own the list, so words removed there don't come back on restart.
+func importBannedWords(db *sql.DB, dbQueries *database.Queries, path string) error {
+	words, err := moderation.LoadWordsFile(path)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := dbQueries.WithTx(tx)
+
+	imported, err := qtx.RecordBannedWordImport(ctx, path)
+	if err != nil {
+		return err
+	}
+	if imported == 0 {
+		return nil
+	}
+	for _, word := range words {
+		if err := qtx.AddBannedWord(ctx, strings.ToLower(word)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("Imported %d banned words from %s", len(words), path)
+	return nil
+}
+
+func envAction(key string, fallback moderation.Action) moderation.Action {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	action, ok := moderation.ParseAction(v)
+	if !ok {
+		log.Printf("Invalid %s %q, using %s", key, v, fallback)
+		return fallback
+	}
+	return action
+}
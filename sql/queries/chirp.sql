@@ -1,28 +1,194 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of, quote_of)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
 -- name: GetChirpsAsc :many
-SELECT * FROM chirps ORDER BY created_at ASC;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = sqlc.narg(viewer_id) OR sqlc.arg(include_hidden)::bool)
+    AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_size);
 
 -- name: GetChirpsDesc :many
-SELECT * FROM chirps ORDER BY created_at DESC;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = sqlc.narg(viewer_id) OR sqlc.arg(include_hidden)::bool)
+    AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
 -- name: GetChirpsByUserIDAsc :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at ASC;
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+    AND deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = sqlc.narg(viewer_id) OR sqlc.arg(include_hidden)::bool)
+    AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_size);
 
 -- name: GetChirpsByUserIDDesc :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at DESC;
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+    AND deleted_at IS NULL
+    AND (hidden_at IS NULL OR user_id = sqlc.narg(viewer_id) OR sqlc.arg(include_hidden)::bool)
+    AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetTimeline :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+    AND (
+        user_id = sqlc.arg(user_id)
+        OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id))
+    )
+    AND (hidden_at IS NULL OR user_id = sqlc.arg(user_id))
+    AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamp, sqlc.arg(cursor_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+    SET body = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: ChirpHasDependents :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_id = sqlc.arg(id)::uuid
+        OR quote_of = sqlc.arg(id)::uuid
+);
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps WHERE rechirp_of = $1;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+    SET body = '',
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetChirpThread :many
+-- Returns the whole conversation a chirp belongs to, depth first with older
+-- replies first. Hidden chirps are included; callers decide who sees them.
+WITH RECURSIVE thread AS (
+    SELECT
+        c.id,
+        0 AS depth,
+        ARRAY[to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text] AS path
+    FROM chirps c
+    WHERE c.id = (SELECT COALESCE(root_id, id) FROM chirps WHERE chirps.id = sqlc.arg(id))
+    UNION ALL
+    SELECT
+        c.id,
+        thread.depth + 1,
+        thread.path || (to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text)
+    FROM chirps c
+    JOIN thread ON c.parent_id = thread.id
+)
+SELECT sqlc.embed(chirps), thread.depth::int AS depth
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.path;
+
+-- name: IncrementChirpLikeCount :exec
+UPDATE chirps SET like_count = like_count + 1 WHERE id = $1;
+
+-- name: DecrementChirpLikeCount :exec
+UPDATE chirps SET like_count = GREATEST(like_count - 1, 0) WHERE id = $1;
+
+-- name: HideChirp :exec
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1;
 
 -- name: DeleteChirpByID :exec
-DELETE FROM chirps WHERE id = $1;
\ No newline at end of file
+DELETE FROM chirps WHERE id = $1;
+
+-- name: SearchChirps :many
+-- The body is HTML-escaped before highlighting, so the <mark> tags are the
+-- only markup in a snippet.
+SELECT
+    sqlc.embed(chirps),
+    ts_rank(chirps.search_vector, q)::real AS rank,
+    ts_headline(
+        'english',
+        replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        q,
+        'StartSel=<mark>, StopSel=</mark>'
+    ) AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg(query)) q
+WHERE chirps.search_vector @@ q
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND (sqlc.narg(author_id)::uuid IS NULL OR chirps.user_id = sqlc.narg(author_id))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_size)
+OFFSET sqlc.arg(page_offset);
+
+-- name: HideChirpsOfDeletedUser :exec
+-- Hidden chirps are stamped with the deletion request, so cancelling the
+-- deletion can tell them apart from chirps hidden by moderators.
+UPDATE chirps
+    SET hidden_at = users.deletion_requested_at
+FROM users
+WHERE chirps.user_id = users.id
+    AND users.id = $1
+    AND users.deletion_requested_at IS NOT NULL
+    AND chirps.hidden_at IS NULL;
+
+-- name: UnhideChirpsOfDeletedUser :exec
+-- Must run before the deletion request is cleared.
+UPDATE chirps
+    SET hidden_at = NULL
+FROM users
+WHERE chirps.user_id = users.id
+    AND users.id = $1
+    AND chirps.hidden_at = users.deletion_requested_at;
+
+-- name: DecrementLikeCountsOfUser :exec
+UPDATE chirps SET like_count = GREATEST(like_count - 1, 0)
+WHERE id IN (SELECT chirp_id FROM chirp_likes WHERE user_id = $1);
+
+-- name: DeleteRechirpsOfUser :exec
+-- Removes other users' rechirps of the user's chirps.
+DELETE FROM chirps
+WHERE rechirp_of IN (SELECT id FROM chirps WHERE user_id = $1);
+
+-- name: TombstoneChirpsOfUser :exec
+UPDATE chirps
+    SET body = '',
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND deleted_at IS NULL
+    AND EXISTS (
+        SELECT 1 FROM chirps d
+        WHERE d.parent_id = chirps.id OR d.quote_of = chirps.id
+    );
+
+-- name: DeleteChirpsOfUser :exec
+-- Tombstones are kept and lose their author once the user is deleted.
+DELETE FROM chirps WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: DeleteChirps :exec
+DELETE FROM chirps;
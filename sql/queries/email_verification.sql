@@ -0,0 +1,13 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens WHERE user_id = $1;
+
+-- name: UseEmailVerificationToken :one
+-- Deletes the token and returns it, unless it has expired.
+DELETE FROM email_verification_tokens
+WHERE token_hash = sqlc.arg(token_hash)
+    AND expires_at > sqlc.arg(now)
+RETURNING *;
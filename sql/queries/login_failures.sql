@@ -0,0 +1,20 @@
+-- name: GetLoginFailures :many
+SELECT * FROM login_failures WHERE key = ANY(sqlc.arg(keys)::text[]);
+
+-- name: RecordLoginFailure :one
+-- Failures older than reset_before are forgotten rather than counted.
+INSERT INTO login_failures (key, failures, last_failure_at)
+VALUES (sqlc.arg(key), 1, sqlc.arg(now))
+ON CONFLICT (key) DO UPDATE
+    SET failures = CASE
+        WHEN login_failures.last_failure_at < sqlc.arg(reset_before) THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failure_at = EXCLUDED.last_failure_at
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures SET locked_until = $2 WHERE key = $1;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures WHERE key = $1;
@@ -0,0 +1,23 @@
+-- name: GetBannedWords :many
+SELECT word FROM banned_words ORDER BY word ASC;
+
+-- name: AddBannedWord :exec
+INSERT INTO banned_words (word, created_at)
+VALUES ($1, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RecordBannedWordImport :execrows
+-- Affects no rows if path was imported before.
+INSERT INTO banned_word_imports (path, imported_at)
+VALUES ($1, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBannedWord :exec
+DELETE FROM banned_words WHERE word = $1;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, created_at, chirp_id, reason)
+VALUES (gen_random_uuid(), NOW(), $1, $2);
+
+-- name: DeleteChirpFlags :exec
+DELETE FROM chirp_flags WHERE chirp_id = $1;
@@ -0,0 +1,16 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: DeletePasswordResetTokens :exec
+DELETE FROM password_reset_tokens WHERE user_id = $1;
+
+-- name: UsePasswordResetToken :one
+-- Marks the token used and returns its user, unless it was used before or
+-- has expired.
+UPDATE password_reset_tokens
+    SET used_at = NOW()
+WHERE token_hash = sqlc.arg(token_hash)
+    AND used_at IS NULL
+    AND expires_at > sqlc.arg(now)
+RETURNING user_id;
@@ -1,18 +1,58 @@
 -- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
-    token,
+    token_hash,
     user_id,
-    expires_at
+    expires_at,
+    family_id,
+    user_agent,
+    ip_address
 )
-VALUES ($1, $2, $3)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1;
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
 
--- name: RevokeRefreshToken :exec
+-- name: RevokeRefreshToken :execrows
 UPDATE refresh_tokens 
     SET revoked = NOW(), 
     updated_at = NOW() 
-WHERE token = $1 
-    AND revoked IS NULL;
\ No newline at end of file
+WHERE token_hash = $1 
+    AND revoked IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+    SET revoked = NOW(),
+    updated_at = NOW()
+WHERE family_id = $1
+    AND revoked IS NULL;
+
+-- name: GetUserSessions :many
+-- Each family has at most one unrevoked token, so every row is one session.
+SELECT
+    rt.family_id,
+    rt.user_agent,
+    rt.ip_address,
+    rt.last_used_at,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id)::timestamp AS started_at
+FROM refresh_tokens rt
+WHERE rt.user_id = $1
+    AND rt.revoked IS NULL
+    AND rt.expires_at > NOW()
+ORDER BY rt.last_used_at DESC;
+
+-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens
+    SET revoked = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND family_id = $2
+    AND revoked IS NULL;
+
+-- name: RevokeUserSessions :execrows
+UPDATE refresh_tokens
+    SET revoked = NOW(),
+    updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+    AND revoked IS NULL
+    AND (sqlc.narg(except_family_id)::uuid IS NULL OR family_id <> sqlc.narg(except_family_id));
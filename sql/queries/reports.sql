@@ -0,0 +1,57 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetModerationQueue :many
+-- Chirps with open reports or moderation flags.
+WITH open_reports AS (
+    SELECT
+        chirp_id,
+        COUNT(*) AS report_count,
+        array_agg(DISTINCT reason) AS reasons,
+        MIN(created_at) AS first_reported_at
+    FROM reports
+    WHERE status = 'open'
+    GROUP BY chirp_id
+), flags AS (
+    SELECT
+        chirp_id,
+        array_agg(DISTINCT reason) AS flags,
+        MIN(created_at) AS first_flagged_at
+    FROM chirp_flags
+    GROUP BY chirp_id
+)
+SELECT
+    chirps.id AS chirp_id,
+    chirps.body,
+    chirps.user_id,
+    chirps.hidden_at,
+    COALESCE(open_reports.report_count, 0)::int AS report_count,
+    COALESCE(open_reports.reasons, '{}')::text[] AS reasons,
+    COALESCE(flags.flags, '{}')::text[] AS flags,
+    LEAST(open_reports.first_reported_at, flags.first_flagged_at)::timestamp AS first_reported_at
+FROM chirps
+LEFT JOIN open_reports ON open_reports.chirp_id = chirps.id
+LEFT JOIN flags ON flags.chirp_id = chirps.id
+WHERE (open_reports.chirp_id IS NOT NULL OR flags.chirp_id IS NOT NULL)
+    AND chirps.deleted_at IS NULL
+ORDER BY report_count DESC, first_reported_at ASC
+LIMIT $1
+OFFSET $2;
+
+-- name: ResolveReports :exec
+UPDATE reports
+    SET status = $1,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE chirp_id = $2
+    AND status = 'open';
@@ -0,0 +1,50 @@
+-- name: UpsertUserTOTP :exec
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret = EXCLUDED.secret,
+    created_at = NOW(),
+    confirmed_at = NULL,
+    last_counter = 0;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: ConfirmUserTOTP :exec
+UPDATE user_totp SET confirmed_at = NOW(), last_counter = $2 WHERE user_id = $1;
+
+-- name: UseTOTPCounter :execrows
+-- Only moves forward, so a code from an already used period is refused even
+-- when two requests race.
+UPDATE user_totp SET last_counter = $2 WHERE user_id = $1 AND last_counter < $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, user_id, code_hash)
+VALUES (gen_random_uuid(), $1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+    SET used_at = NOW()
+WHERE user_id = $1
+    AND code_hash = $2
+    AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: CreateLoginChallenge :exec
+INSERT INTO login_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges WHERE token_hash = $1;
+
+-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges SET attempts = attempts + 1 WHERE token_hash = $1
+RETURNING attempts;
+
+-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges WHERE token_hash = $1;
@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
@@ -15,12 +16,16 @@ SELECT * FROM users WHERE email = $1;
 -- name: GetUserByID :one
 SELECT * FROM users WHERE id = $1;
 
+-- name: GetUsersByHandles :many
+SELECT * FROM users WHERE handle = ANY(sqlc.arg(handles)::text[]);
+
 -- name: UpdateUsersPasswordAndEmail :one
 UPDATE users 
     SET hashed_password = $1, 
     email = $2, 
+    handle = COALESCE($3, handle),
     updated_at = NOW() 
-WHERE id = $3
+WHERE id = $4
 RETURNING *;
 
 -- name: DeleteUsers :exec
@@ -31,4 +36,71 @@ UPDATE users
     SET is_chirpy_red = TRUE, 
     updated_at = NOW() 
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+    SET role = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+    SET hashed_password = $1,
+    updated_at = NOW()
+WHERE id = $2;
+
+-- name: VerifyUserEmail :one
+-- Also replaces the email, for a confirmed email change.
+UPDATE users
+    SET email = $1,
+    email_verified_at = NOW(),
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserEmail :exec
+UPDATE users
+    SET email = $1,
+    updated_at = NOW()
+WHERE id = $2;
+
+-- name: UpdateUserHandle :exec
+UPDATE users
+    SET handle = $1,
+    updated_at = NOW()
+WHERE id = $2;
+
+-- name: UpdateUserProfile :exec
+-- Fields passed as NULL are left unchanged.
+UPDATE users
+    SET display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    location = COALESCE(sqlc.narg(location), location),
+    website = COALESCE(sqlc.narg(website), website),
+    avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: RequestUserDeletion :one
+UPDATE users
+    SET deletion_requested_at = $1,
+    updated_at = NOW()
+WHERE id = $2
+    AND deletion_requested_at IS NULL
+RETURNING *;
+
+-- name: CancelUserDeletion :exec
+UPDATE users
+    SET deletion_requested_at = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetUsersToPurge :many
+SELECT id FROM users
+WHERE deletion_requested_at IS NOT NULL
+    AND deletion_requested_at < $1;
+
+-- name: DeleteUserByID :exec
+DELETE FROM users WHERE id = $1;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    chirp_id UUID NOT NULL,
+    reporter_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open',
+    resolved_at TIMESTAMP DEFAULT NULL,
+    FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE,
+    FOREIGN KEY (reporter_id) REFERENCES users (id) ON DELETE CASCADE,
+    UNIQUE (chirp_id, reporter_id)
+);
+CREATE INDEX reports_status_chirp_id_idx ON reports (status, chirp_id);
+
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN hidden_at;
+DROP TABLE reports;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Only a SHA-256 of each refresh token is kept. Hashing the existing rows in
+-- place keeps everyone logged in, since clients still hold the raw tokens.
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- The raw tokens can't be recovered, so rolling back logs everyone out.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
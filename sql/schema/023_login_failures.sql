@@ -0,0 +1,13 @@
+-- +goose Up
+-- Failed logins per key, where a key is either "email:<address>" or
+-- "ip:<address>". Emails without an account are tracked too, so lockouts
+-- don't reveal which emails exist.
+CREATE TABLE login_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE login_failures;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Accounts that existed before verification was introduced count as
+-- verified, so turning on REQUIRE_VERIFIED_EMAIL doesn't lock them out.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+UPDATE users SET email_verified_at = created_at;
+
+-- email is the address the token confirms. For a new account it is the
+-- account's email; for an email change it is the new address, which only
+-- replaces the old one once confirmed.
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+ALTER TABLE users DROP COLUMN email_verified_at;
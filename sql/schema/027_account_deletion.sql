@@ -0,0 +1,9 @@
+-- +goose Up
+-- Set while an account waits to be purged. Logging in clears it again.
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMP;
+
+CREATE INDEX users_deletion_requested_at_idx ON users (deletion_requested_at)
+    WHERE deletion_requested_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN deletion_requested_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- BANNED_WORDS_FILE is imported once per path, so words deleted through the
+-- API stay deleted across restarts.
+CREATE TABLE banned_word_imports (
+    path TEXT PRIMARY KEY,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE banned_word_imports;